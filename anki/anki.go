@@ -0,0 +1,199 @@
+// Package anki writes Anki deck packages (.apkg): a zip holding an SQLite
+// collection in the legacy collection.anki2 schema, which every Anki release
+// can import. Notes carry GUIDs derived from caller-supplied keys, so
+// re-importing an updated deck updates existing notes instead of duplicating
+// them.
+package anki
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Note is one two-sided card. Front and Back are HTML.
+type Note struct {
+	Key   string // stable identity, e.g. a concept ID
+	Front string
+	Back  string
+	Tags  []string // must not contain spaces
+}
+
+// Deck is a named set of notes that share one "Front/Back" note type.
+type Deck struct {
+	Name        string
+	Description string
+	CSS         string // styling for the note type
+	Notes       []Note // in the order new cards should be introduced
+}
+
+// stableID maps s to a positive integer usable as an Anki object ID. Anki
+// IDs are conventionally creation timestamps in milliseconds, so IDs are
+// kept in that range.
+func stableID(s string) int64 {
+	sum := sha256.Sum256([]byte(s))
+	return 1_500_000_000_000 + int64(binary.BigEndian.Uint64(sum[:8])%100_000_000_000)*2
+}
+
+const base91 = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!#$%&()*+,-./:;<=>?@[]^_`{|}~"
+
+// guid derives the note GUID from its key, encoded the way Anki encodes
+// its own 64-bit GUIDs.
+func guid(deck, key string) string {
+	sum := sha256.Sum256([]byte(deck + "\x00" + key))
+	v := binary.BigEndian.Uint64(sum[:8])
+	var out []byte
+	for v > 0 {
+		out = append(out, base91[v%91])
+		v /= 91
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+var tagRE = regexp.MustCompile(`<[^>]*>`)
+
+// sortField is the plain-text form of the first field, which Anki uses for
+// sorting and duplicate checks.
+func sortField(s string) string {
+	return strings.TrimSpace(html.UnescapeString(tagRE.ReplaceAllString(s, " ")))
+}
+
+func checksum(s string) int64 {
+	sum := sha1.Sum([]byte(s))
+	v, _ := strconv.ParseInt(hex.EncodeToString(sum[:4]), 16, 64)
+	return v
+}
+
+// WritePackage writes the deck as an .apkg to w.
+func (d *Deck) WritePackage(w io.Writer, now time.Time) error {
+	var db bytes.Buffer
+	if err := writeSQLite(&db, d.tables(now)); err != nil {
+		return err
+	}
+	zw := zip.NewWriter(w)
+	f, err := zw.Create("collection.anki2")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(db.Bytes()); err != nil {
+		return err
+	}
+	if f, err = zw.Create("media"); err != nil {
+		return err
+	}
+	if _, err := f.Write([]byte("{}")); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func (d *Deck) tables(now time.Time) []table {
+	deckID := stableID("deck:" + d.Name)
+	modelID := stableID("model:" + d.Name)
+	secs := now.Unix()
+	ms := now.UnixMilli()
+
+	notes := make([]row, len(d.Notes))
+	cards := make([]row, len(d.Notes))
+	for i, n := range d.Notes {
+		nid := stableID("note:" + d.Name + "\x00" + n.Key)
+		sfld := sortField(n.Front)
+		tags := ""
+		if len(n.Tags) > 0 {
+			tags = " " + strings.Join(n.Tags, " ") + " "
+		}
+		notes[i] = row{rowid: nid, values: []any{
+			nil, guid(d.Name, n.Key), modelID, secs, int64(-1), tags,
+			n.Front + "\x1f" + n.Back, sfld, checksum(sfld), int64(0), "",
+		}}
+		cards[i] = row{rowid: nid + 1, values: []any{
+			nil, nid, deckID, int64(0), secs, int64(-1),
+			int64(0), int64(0), int64(i + 1), // new card, new queue, position
+			int64(0), int64(0), int64(0), int64(0), int64(0), int64(0), int64(0), int64(0), "",
+		}}
+	}
+
+	col := row{rowid: 1, values: []any{
+		nil,
+		now.Truncate(24 * time.Hour).Unix(), // crt: collection creation day
+		ms, ms, int64(11), int64(0), int64(0), int64(0),
+		mustJSON(map[string]any{
+			"activeDecks": []int64{1}, "curDeck": 1, "newSpread": 0, "collapseTime": 1200,
+			"timeLim": 0, "estTimes": true, "dueCounts": true, "curModel": strconv.FormatInt(modelID, 10),
+			"nextPos": len(d.Notes) + 1, "sortType": "noteFld", "sortBackwards": false, "addToCur": true,
+		}),
+		mustJSON(map[string]any{strconv.FormatInt(modelID, 10): d.model(modelID, deckID, secs)}),
+		mustJSON(map[string]any{
+			"1":                           deckJSON(1, "Default", "", 0),
+			strconv.FormatInt(deckID, 10): deckJSON(deckID, d.Name, d.Description, secs),
+		}),
+		mustJSON(map[string]any{"1": defaultDeckConfig}),
+		"{}",
+	}}
+
+	return []table{
+		{name: "col", sql: "CREATE TABLE col (id integer primary key, crt integer not null, mod integer not null, scm integer not null, ver integer not null, dty integer not null, usn integer not null, ls integer not null, conf text not null, models text not null, decks text not null, dconf text not null, tags text not null)", rows: []row{col}},
+		{name: "notes", sql: "CREATE TABLE notes (id integer primary key, guid text not null, mid integer not null, mod integer not null, usn integer not null, tags text not null, flds text not null, sfld integer not null, csum integer not null, flags integer not null, data text not null)", rows: notes},
+		{name: "cards", sql: "CREATE TABLE cards (id integer primary key, nid integer not null, did integer not null, ord integer not null, mod integer not null, usn integer not null, type integer not null, queue integer not null, due integer not null, ivl integer not null, factor integer not null, reps integer not null, lapses integer not null, left integer not null, odue integer not null, odid integer not null, flags integer not null, data text not null)", rows: cards},
+		{name: "revlog", sql: "CREATE TABLE revlog (id integer primary key, cid integer not null, usn integer not null, ease integer not null, ivl integer not null, lastIvl integer not null, factor integer not null, time integer not null, type integer not null)"},
+		{name: "graves", sql: "CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null)"},
+	}
+}
+
+func (d *Deck) model(modelID, deckID, secs int64) map[string]any {
+	field := func(name string, ord int) map[string]any {
+		return map[string]any{"name": name, "ord": ord, "sticky": false, "rtl": false, "font": "Arial", "size": 20, "media": []any{}}
+	}
+	return map[string]any{
+		"id": modelID, "name": d.Name, "type": 0, "mod": secs, "usn": -1, "sortf": 0, "did": deckID,
+		"tmpls": []any{map[string]any{
+			"name": "Card 1", "ord": 0, "did": nil, "bqfmt": "", "bafmt": "",
+			"qfmt": "{{Front}}",
+			"afmt": "{{FrontSide}}<hr id=answer>{{Back}}",
+		}},
+		"flds":      []any{field("Front", 0), field("Back", 1)},
+		"css":       d.CSS,
+		"latexPre":  "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage[utf8]{inputenc}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\setlength{\\parindent}{0in}\n\\begin{document}\n",
+		"latexPost": "\\end{document}",
+		"tags":      []any{},
+		"vers":      []any{},
+		"req":       []any{[]any{0, "any", []int{0}}},
+	}
+}
+
+func deckJSON(id int64, name, desc string, secs int64) map[string]any {
+	return map[string]any{
+		"id": id, "name": name, "desc": desc, "mod": secs, "usn": -1, "collapsed": false,
+		"newToday": []int{0, 0}, "revToday": []int{0, 0}, "lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+		"dyn": 0, "conf": 1, "extendNew": 10, "extendRev": 50,
+	}
+}
+
+var defaultDeckConfig = map[string]any{
+	"id": 1, "name": "Default", "replayq": true, "timer": 0, "maxTaken": 60, "usn": 0, "mod": 0,
+	"autoplay": true, "dyn": false,
+	"lapse": map[string]any{"delays": []int{10}, "mult": 0, "minInt": 1, "leechFails": 8, "leechAction": 0},
+	"rev":   map[string]any{"perDay": 100, "ease4": 1.3, "fuzz": 0.05, "minSpace": 1, "ivlFct": 1, "maxIvl": 36500, "bury": true},
+	"new":   map[string]any{"delays": []int{1, 10}, "ints": []int{1, 4, 7}, "initialFactor": 2500, "separate": true, "order": 1, "perDay": 20, "bury": true},
+}
+
+func mustJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,350 @@
+package anki
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// This file writes a minimal SQLite 3 database: ordinary rowid tables only,
+// no indexes, no free pages. That is all an Anki collection needs and keeps
+// the package free of a database driver. The layout follows
+// https://www.sqlite.org/fileformat2.html.
+
+const (
+	pageSize   = 4096
+	usable     = pageSize // no reserved bytes per page
+	headerSize = 100      // database header at the start of page 1
+
+	leafTable     = 0x0d
+	interiorTable = 0x05
+
+	// Payload thresholds for table b-tree leaf cells (section 1.6).
+	maxLocal = usable - 35
+	minLocal = (usable-12)*32/255 - 23
+)
+
+// table is one rowid table to write.
+type table struct {
+	name string
+	sql  string // CREATE TABLE statement stored in sqlite_schema
+	rows []row
+}
+
+// row is a table row. Values may be nil, int64, float64, string or []byte.
+// Columns declared INTEGER PRIMARY KEY alias the rowid and must be nil.
+type row struct {
+	rowid  int64
+	values []any
+}
+
+type pager struct {
+	pages [][]byte // pages[0] is page 1
+}
+
+func (p *pager) alloc() (uint32, []byte) {
+	b := make([]byte, pageSize)
+	p.pages = append(p.pages, b)
+	return uint32(len(p.pages)), b
+}
+
+// writeSQLite writes a database containing tables to w.
+func writeSQLite(w io.Writer, tables []table) error {
+	p := &pager{}
+	p.alloc() // page 1: header and sqlite_schema root, filled in last
+
+	schema := make([]row, len(tables))
+	for i, t := range tables {
+		root, err := p.buildTable(t.rows)
+		if err != nil {
+			return fmt.Errorf("table %s: %w", t.name, err)
+		}
+		schema[i] = row{rowid: int64(i + 1), values: []any{"table", t.name, t.name, int64(root), t.sql}}
+	}
+
+	// sqlite_schema must fit on page 1 since its root page is fixed there.
+	cells := make([][]byte, len(schema))
+	for i, r := range schema {
+		cell, err := p.leafCell(r)
+		if err != nil {
+			return err
+		}
+		cells[i] = cell
+	}
+	if !fits(headerSize, 8, cells) {
+		return fmt.Errorf("schema does not fit on the first page")
+	}
+	page1 := p.pages[0]
+	writeBtreePage(page1, headerSize, leafTable, cells, 0)
+	writeHeader(page1, uint32(len(p.pages)))
+
+	for _, pg := range p.pages {
+		if _, err := w.Write(pg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeHeader(b []byte, npages uint32) {
+	copy(b, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(b[16:], pageSize)
+	b[18], b[19] = 1, 1 // legacy journal mode read/write versions
+	b[20] = 0           // reserved bytes per page
+	b[21], b[22], b[23] = 64, 32, 32
+	binary.BigEndian.PutUint32(b[24:], 1) // file change counter
+	binary.BigEndian.PutUint32(b[28:], npages)
+	binary.BigEndian.PutUint32(b[40:], 1) // schema cookie
+	binary.BigEndian.PutUint32(b[44:], 4) // schema format number
+	binary.BigEndian.PutUint32(b[56:], 1) // UTF-8
+	binary.BigEndian.PutUint32(b[92:], 1) // version-valid-for, matches change counter
+	binary.BigEndian.PutUint32(b[96:], 3046000)
+}
+
+// buildTable writes rows as a table b-tree and returns its root page.
+func (p *pager) buildTable(rows []row) (uint32, error) {
+	sort.Slice(rows, func(i, j int) bool { return rows[i].rowid < rows[j].rowid })
+
+	type child struct {
+		page   uint32
+		maxKey int64
+	}
+	var level []child
+
+	// Leaves: pack cells greedily.
+	var cells [][]byte
+	var lastKey int64
+	flush := func() {
+		pg, b := p.alloc()
+		writeBtreePage(b, 0, leafTable, cells, 0)
+		level = append(level, child{pg, lastKey})
+		cells = nil
+	}
+	for _, r := range rows {
+		cell, err := p.leafCell(r)
+		if err != nil {
+			return 0, err
+		}
+		if len(cells) > 0 && !fits(0, 8, append(cells, cell)) {
+			flush()
+		}
+		cells = append(cells, cell)
+		lastKey = r.rowid
+	}
+	if len(cells) > 0 || len(level) == 0 {
+		flush()
+	}
+
+	// Interior levels until a single root remains. Each page's last child
+	// becomes its right-most pointer, so a page holding n children has n-1
+	// cells.
+	for len(level) > 1 {
+		var groups [][]child
+		var group []child
+		for _, c := range level {
+			icells := make([][]byte, len(group))
+			for i, g := range group {
+				icells[i] = interiorCell(g.page, g.maxKey)
+			}
+			if len(group) > 1 && !fits(0, 12, icells) {
+				groups = append(groups, group)
+				group = nil
+			}
+			group = append(group, c)
+		}
+		groups = append(groups, group)
+		// Avoid a trailing page with no cells by borrowing a sibling.
+		if n := len(groups); n > 1 && len(groups[n-1]) == 1 {
+			prev := groups[n-2]
+			groups[n-1] = append([]child{prev[len(prev)-1]}, groups[n-1]...)
+			groups[n-2] = prev[:len(prev)-1]
+		}
+
+		var next []child
+		for _, g := range groups {
+			icells := make([][]byte, len(g)-1)
+			for i, c := range g[:len(g)-1] {
+				icells[i] = interiorCell(c.page, c.maxKey)
+			}
+			pg, b := p.alloc()
+			last := g[len(g)-1]
+			writeBtreePage(b, 0, interiorTable, icells, last.page)
+			next = append(next, child{pg, last.maxKey})
+		}
+		level = next
+	}
+	return level[0].page, nil
+}
+
+func interiorCell(page uint32, key int64) []byte {
+	b := binary.BigEndian.AppendUint32(nil, page)
+	return appendVarint(b, uint64(key))
+}
+
+// leafCell encodes a table leaf cell, spilling large payloads to overflow
+// pages.
+func (p *pager) leafCell(r row) ([]byte, error) {
+	payload, err := encodeRecord(r.values)
+	if err != nil {
+		return nil, err
+	}
+	cell := appendVarint(nil, uint64(len(payload)))
+	cell = appendVarint(cell, uint64(r.rowid))
+
+	n := len(payload)
+	if n <= maxLocal {
+		return append(cell, payload...), nil
+	}
+	local := minLocal + (n-minLocal)%(usable-4)
+	if local > maxLocal {
+		local = minLocal
+	}
+	cell = append(cell, payload[:local]...)
+	rest := payload[local:]
+
+	// Allocate the chain front to back, linking each page to the next.
+	var first uint32
+	var prev []byte
+	for len(rest) > 0 {
+		pg, b := p.alloc()
+		if prev == nil {
+			first = pg
+		} else {
+			binary.BigEndian.PutUint32(prev, pg)
+		}
+		k := copy(b[4:], rest)
+		rest = rest[k:]
+		prev = b
+	}
+	return binary.BigEndian.AppendUint32(cell, first), nil
+}
+
+// fits reports whether cells fit on a page whose b-tree header of hdrLen
+// bytes starts at off.
+func fits(off, hdrLen int, cells [][]byte) bool {
+	size := off + hdrLen + 2*len(cells)
+	for _, c := range cells {
+		size += len(c)
+	}
+	return size <= usable
+}
+
+// writeBtreePage lays out a b-tree page: header at off, cell pointer array
+// after it and cell content packed against the end of the page.
+func writeBtreePage(b []byte, off int, flag byte, cells [][]byte, rightPtr uint32) {
+	hdrLen := 8
+	if flag == interiorTable {
+		hdrLen = 12
+	}
+	content := usable
+	ptr := off + hdrLen
+	for _, c := range cells {
+		content -= len(c)
+		copy(b[content:], c)
+		binary.BigEndian.PutUint16(b[ptr:], uint16(content))
+		ptr += 2
+	}
+	b[off] = flag
+	binary.BigEndian.PutUint16(b[off+1:], 0) // first freeblock
+	binary.BigEndian.PutUint16(b[off+3:], uint16(len(cells)))
+	binary.BigEndian.PutUint16(b[off+5:], uint16(content%65536))
+	b[off+7] = 0 // fragmented free bytes
+	if flag == interiorTable {
+		binary.BigEndian.PutUint32(b[off+8:], rightPtr)
+	}
+}
+
+// encodeRecord encodes values in the SQLite record format.
+func encodeRecord(values []any) ([]byte, error) {
+	var types, body []byte
+	for _, v := range values {
+		switch v := v.(type) {
+		case nil:
+			types = appendVarint(types, 0)
+		case int:
+			types, body = appendInt(types, body, int64(v))
+		case int64:
+			types, body = appendInt(types, body, v)
+		case float64:
+			types = appendVarint(types, 7)
+			body = binary.BigEndian.AppendUint64(body, math.Float64bits(v))
+		case string:
+			types = appendVarint(types, uint64(2*len(v)+13))
+			body = append(body, v...)
+		case []byte:
+			types = appendVarint(types, uint64(2*len(v)+12))
+			body = append(body, v...)
+		default:
+			return nil, fmt.Errorf("unsupported value type %T", v)
+		}
+	}
+	// The header length counts its own varint.
+	hdrLen := len(types) + 1
+	for varintLen(uint64(hdrLen)) != hdrLen-len(types) {
+		hdrLen = len(types) + varintLen(uint64(hdrLen))
+	}
+	rec := appendVarint(make([]byte, 0, hdrLen+len(body)), uint64(hdrLen))
+	rec = append(rec, types...)
+	return append(rec, body...), nil
+}
+
+// appendInt uses the smallest integer serial type that holds v.
+func appendInt(types, body []byte, v int64) ([]byte, []byte) {
+	switch {
+	case v == 0:
+		return appendVarint(types, 8), body
+	case v == 1:
+		return appendVarint(types, 9), body
+	case v >= math.MinInt8 && v <= math.MaxInt8:
+		return appendVarint(types, 1), append(body, byte(v))
+	case v >= math.MinInt16 && v <= math.MaxInt16:
+		return appendVarint(types, 2), binary.BigEndian.AppendUint16(body, uint16(v))
+	case v >= -1<<23 && v < 1<<23:
+		return appendVarint(types, 3), append(body, byte(v>>16), byte(v>>8), byte(v))
+	case v >= math.MinInt32 && v <= math.MaxInt32:
+		return appendVarint(types, 4), binary.BigEndian.AppendUint32(body, uint32(v))
+	case v >= -1<<47 && v < 1<<47:
+		return appendVarint(types, 5), append(body, byte(v>>40), byte(v>>32), byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	default:
+		return appendVarint(types, 6), binary.BigEndian.AppendUint64(body, uint64(v))
+	}
+}
+
+// appendVarint appends v as an SQLite varint: big-endian base 128, at most
+// nine bytes, with the ninth byte contributing all eight bits.
+func appendVarint(b []byte, v uint64) []byte {
+	if v > 1<<56-1 {
+		var buf [9]byte
+		buf[8] = byte(v)
+		v >>= 8
+		for i := 7; i >= 0; i-- {
+			buf[i] = byte(v&0x7f) | 0x80
+			v >>= 7
+		}
+		return append(b, buf[:]...)
+	}
+	var tmp [8]byte
+	n := 0
+	for {
+		tmp[n] = byte(v & 0x7f)
+		n++
+		v >>= 7
+		if v == 0 {
+			break
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		c := tmp[i]
+		if i > 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+func varintLen(v uint64) int {
+	return len(appendVarint(nil, v))
+}
@@ -0,0 +1,342 @@
+package anki
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The tests read databases back with a small reader written from the file
+// format document, independent of the writer's helpers, since the standard
+// library has no SQLite driver.
+
+func readVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := range 8 {
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return v<<8 | uint64(b[8]), 9
+}
+
+type dbReader struct {
+	data []byte
+	t    *testing.T
+	seen map[uint32]bool // pages reached, to catch sharing and leaks
+}
+
+func (r *dbReader) page(n uint32) []byte {
+	r.t.Helper()
+	if n < 1 || int(n)*pageSize > len(r.data) {
+		r.t.Fatalf("page %d is outside the file", n)
+	}
+	if r.seen[n] {
+		r.t.Fatalf("page %d is used twice", n)
+	}
+	r.seen[n] = true
+	return r.data[(n-1)*pageSize : n*pageSize]
+}
+
+// walk visits the rows of the table b-tree rooted at root in rowid order.
+func (r *dbReader) walk(root uint32, visit func(rowid int64, payload []byte)) {
+	r.t.Helper()
+	b := r.page(root)
+	off := 0
+	if root == 1 {
+		off = headerSize
+	}
+	n := int(binary.BigEndian.Uint16(b[off+3:]))
+	switch b[off] {
+	case interiorTable:
+		for i := range n {
+			cell := b[binary.BigEndian.Uint16(b[off+12+2*i:]):]
+			r.walk(binary.BigEndian.Uint32(cell), visit)
+		}
+		r.walk(binary.BigEndian.Uint32(b[off+8:]), visit)
+	case leafTable:
+		for i := range n {
+			cell := b[binary.BigEndian.Uint16(b[off+8+2*i:]):]
+			size, k := readVarint(cell)
+			rowid, k2 := readVarint(cell[k:])
+			cell = cell[k+k2:]
+			payload := r.payload(cell, int(size))
+			visit(int64(rowid), payload)
+		}
+	default:
+		r.t.Fatalf("page %d has b-tree flag %#x", root, b[off])
+	}
+}
+
+// payload reassembles a cell's payload, following its overflow chain.
+func (r *dbReader) payload(cell []byte, size int) []byte {
+	r.t.Helper()
+	maxL, minL := usable-35, (usable-12)*32/255-23
+	if size <= maxL {
+		return cell[:size]
+	}
+	local := minL + (size-minL)%(usable-4)
+	if local > maxL {
+		local = minL
+	}
+	out := append([]byte(nil), cell[:local]...)
+	next := binary.BigEndian.Uint32(cell[local:])
+	for len(out) < size {
+		if next == 0 {
+			r.t.Fatalf("overflow chain ends %d bytes short", size-len(out))
+		}
+		b := r.page(next)
+		next = binary.BigEndian.Uint32(b)
+		out = append(out, b[4:4+min(usable-4, size-len(out))]...)
+	}
+	if next != 0 {
+		r.t.Fatal("overflow chain is longer than the payload")
+	}
+	return out
+}
+
+func decodeRecord(t *testing.T, rec []byte) []any {
+	t.Helper()
+	hdrLen, k := readVarint(rec)
+	hdr, body := rec[k:hdrLen], rec[hdrLen:]
+	var values []any
+	for len(hdr) > 0 {
+		st, k := readVarint(hdr)
+		hdr = hdr[k:]
+		intOf := func(n int) int64 {
+			var v int64
+			for _, c := range body[:n] {
+				v = v<<8 | int64(c)
+			}
+			body = body[n:]
+			return v << (64 - 8*n) >> (64 - 8*n) // sign-extend
+		}
+		switch {
+		case st == 0:
+			values = append(values, nil)
+		case st >= 1 && st <= 4:
+			values = append(values, intOf(int(st)))
+		case st == 5:
+			values = append(values, intOf(6))
+		case st == 6:
+			values = append(values, intOf(8))
+		case st == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(body)))
+			body = body[8:]
+		case st == 8, st == 9:
+			values = append(values, int64(st-8))
+		case st >= 12 && st%2 == 0:
+			n := int(st-12) / 2
+			values = append(values, append([]byte(nil), body[:n]...))
+			body = body[n:]
+		case st >= 13:
+			n := int(st-13) / 2
+			values = append(values, string(body[:n]))
+			body = body[n:]
+		default:
+			t.Fatalf("reserved serial type %d", st)
+		}
+	}
+	if len(body) != 0 {
+		t.Fatalf("record has %d bytes past its last value", len(body))
+	}
+	return values
+}
+
+// readSQLite checks the header and page layout of a database written by
+// writeSQLite and returns each table's rows.
+func readSQLite(t *testing.T, data []byte) map[string][]row {
+	t.Helper()
+	if len(data)%pageSize != 0 {
+		t.Fatalf("file is %d bytes, not a whole number of pages", len(data))
+	}
+	if !bytes.HasPrefix(data, []byte("SQLite format 3\x00")) {
+		t.Fatal("missing SQLite header string")
+	}
+	if got := binary.BigEndian.Uint16(data[16:]); got != pageSize {
+		t.Fatalf("page size = %d, want %d", got, pageSize)
+	}
+	if got, want := binary.BigEndian.Uint32(data[28:]), uint32(len(data)/pageSize); got != want {
+		t.Fatalf("header page count = %d, file has %d", got, want)
+	}
+	if binary.BigEndian.Uint32(data[24:]) != binary.BigEndian.Uint32(data[92:]) {
+		t.Fatal("page count is not marked valid for this change counter")
+	}
+	if got := binary.BigEndian.Uint32(data[56:]); got != 1 {
+		t.Fatalf("text encoding = %d, want 1 (UTF-8)", got)
+	}
+
+	r := &dbReader{data: data, t: t, seen: make(map[uint32]bool)}
+	tables := make(map[string][]row)
+	var roots []struct {
+		name string
+		root uint32
+	}
+	r.walk(1, func(_ int64, payload []byte) {
+		v := decodeRecord(t, payload)
+		if len(v) != 5 || v[0] != "table" {
+			t.Fatalf("schema row = %v", v)
+		}
+		roots = append(roots, struct {
+			name string
+			root uint32
+		}{v[1].(string), uint32(v[3].(int64))})
+	})
+	for _, tr := range roots {
+		rows := []row{}
+		last := int64(math.MinInt64)
+		r.walk(tr.root, func(rowid int64, payload []byte) {
+			if rowid <= last {
+				t.Fatalf("table %s: rowid %d after %d", tr.name, rowid, last)
+			}
+			last = rowid
+			rows = append(rows, row{rowid: rowid, values: decodeRecord(t, payload)})
+		})
+		tables[tr.name] = rows
+	}
+	if len(r.seen) != len(data)/pageSize {
+		t.Fatalf("%d of %d pages are reachable", len(r.seen), len(data)/pageSize)
+	}
+	return tables
+}
+
+func TestVarint(t *testing.T) {
+	tests := []struct {
+		v   uint64
+		len int
+	}{
+		{0, 1}, {127, 1}, {128, 2}, {16383, 2}, {16384, 3},
+		{1<<56 - 1, 8}, {1 << 56, 9}, {math.MaxUint64, 9},
+	}
+	for _, tt := range tests {
+		b := appendVarint(nil, tt.v)
+		if len(b) != tt.len {
+			t.Errorf("appendVarint(%d) is %d bytes, want %d", tt.v, len(b), tt.len)
+		}
+		if got, n := readVarint(append(b, 0xff)); got != tt.v || n != len(b) {
+			t.Errorf("varint %d reads back as %d in %d bytes", tt.v, got, n)
+		}
+	}
+}
+
+func TestEncodeRecord(t *testing.T) {
+	values := []any{
+		nil, int64(0), int64(1), int64(-1), int64(127), int64(-129), int64(1 << 22),
+		int64(-1 << 31), int64(1 << 40), int64(math.MinInt64), 2.5, "", "héllo", []byte{0, 1, 2},
+		strings.Repeat("x", 200), // a header entry of two bytes
+	}
+	rec, err := encodeRecord(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := decodeRecord(t, rec)
+	want := append([]any(nil), values...)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("record decodes as %v, want %v", got, want)
+	}
+	if _, err := encodeRecord([]any{true}); err == nil {
+		t.Error("encodeRecord(bool) succeeded, want an error")
+	}
+}
+
+func TestWriteSQLite(t *testing.T) {
+	// Enough rows for hundreds of leaves, which take two levels of
+	// interior pages.
+	many := make([]row, 12000)
+	pad := strings.Repeat("-", 200)
+	for i := range many {
+		many[i] = row{rowid: int64(i*7 + 3), values: []any{nil, fmt.Sprintf("row %d %s", i, pad), int64(i)}}
+	}
+	large := []row{
+		{rowid: 1, values: []any{nil, strings.Repeat("a", maxLocal)}},      // just fits on the page
+		{rowid: 2, values: []any{nil, strings.Repeat("b", maxLocal+1)}},    // spills
+		{rowid: 3, values: []any{nil, strings.Repeat("c", 3*pageSize+17)}}, // a chain of overflow pages
+		{rowid: 4, values: []any{nil, []byte(strings.Repeat("d", 20000))}},
+	}
+	tables := []table{
+		{name: "empty", sql: "CREATE TABLE empty (id integer primary key)"},
+		{name: "many", sql: "CREATE TABLE many (id integer primary key, name text, n integer)", rows: many},
+		{name: "large", sql: "CREATE TABLE large (id integer primary key, body)", rows: large},
+	}
+
+	var buf bytes.Buffer
+	if err := writeSQLite(&buf, tables); err != nil {
+		t.Fatal(err)
+	}
+	got := readSQLite(t, buf.Bytes())
+	for _, tt := range tables {
+		want := tt.rows
+		if want == nil {
+			want = []row{}
+		}
+		if !reflect.DeepEqual(got[tt.name], want) {
+			t.Errorf("table %s: read back %d rows that differ from the %d written", tt.name, len(got[tt.name]), len(want))
+		}
+	}
+}
+
+func TestWritePackage(t *testing.T) {
+	deck := Deck{
+		Name: "Test Deck",
+		Notes: []Note{
+			{Key: "mutex", Front: "<div>Mutex &amp; friends</div>", Back: "Lock it", Tags: []string{"Concurrency"}},
+			{Key: "maps", Front: "Maps", Back: strings.Repeat("long answer ", 1000)},
+		},
+	}
+	var pkg bytes.Buffer
+	if err := deck.WritePackage(&pkg, time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(pkg.Bytes()), int64(pkg.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name], err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if string(files["media"]) != "{}" {
+		t.Errorf("media = %q, want {}", files["media"])
+	}
+	got := readSQLite(t, files["collection.anki2"])
+
+	notes := got["notes"]
+	if len(notes) != 2 || len(got["cards"]) != 2 || len(got["col"]) != 1 {
+		t.Fatalf("got %d notes, %d cards and %d collections; want 2, 2 and 1", len(notes), len(got["cards"]), len(got["col"]))
+	}
+	byKey := make(map[string][]any)
+	for _, n := range notes {
+		byKey[n.values[1].(string)] = n.values
+	}
+	mutex := byKey[guid(deck.Name, "mutex")]
+	if mutex == nil {
+		t.Fatal("no note has the GUID derived from key mutex")
+	}
+	if mutex[6] != "<div>Mutex &amp; friends</div>\x1fLock it" || mutex[7] != "Mutex & friends" || mutex[5] != " Concurrency " {
+		t.Errorf("mutex note fields = %q, sort field %q, tags %q", mutex[6], mutex[7], mutex[5])
+	}
+	if long := byKey[guid(deck.Name, "maps")]; long == nil || !strings.HasSuffix(long[6].(string), "long answer ") {
+		t.Error("the long note did not survive its overflow pages")
+	}
+
+	// GUIDs depend only on the deck and key, so a re-import updates notes.
+	if guid(deck.Name, "mutex") != guid("Test Deck", "mutex") || guid(deck.Name, "mutex") == guid(deck.Name, "maps") {
+		t.Error("GUIDs are not stable per key")
+	}
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go-concept-trainer/search"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// writeJSON writes v as a JSON response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error body of the form {"error": msg}.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// conceptIndex answers lookups and filtered listings over the loaded
+// concepts, which are immutable for the life of the process.
+type conceptIndex struct {
+	list     []Concept // ordered by Number
+	byID     map[string]int
+	byNumber map[int]int
+	fields   []map[string]json.RawMessage // per concept, keyed by JSON field name
+	exam     []map[string]json.RawMessage // the same, as forExam shows them
+	known    map[string]bool              // union of field names, for ?fields= validation
+	all      *precompressed               // unfiltered list, served for bare requests
+	text     *search.Index
+
+	// exams hides answers from learners sitting an exam; set by main.
+	exams *examStore
+	// grader runs predict programs for the Anki export; set by main.
+	grader *grader
+}
+
+func newConceptIndex(all []Concept) (*conceptIndex, error) {
+	list := slices.Clone(all)
+	slices.SortStableFunc(list, func(a, b Concept) int { return a.Number - b.Number })
+
+	idx := &conceptIndex{
+		list:     list,
+		byID:     make(map[string]int, len(list)),
+		byNumber: make(map[int]int, len(list)),
+		fields:   make([]map[string]json.RawMessage, len(list)),
+		exam:     make([]map[string]json.RawMessage, len(list)),
+		known:    make(map[string]bool),
+		text: search.New(
+			search.Field{Name: "name", Weight: 3},
+			search.Field{Name: "description", Weight: 2},
+			search.Field{Name: "explanation", Weight: 1},
+			search.Field{Name: "example", Weight: 1},
+			search.Field{Name: "useCase", Weight: 1},
+		),
+	}
+	for i, c := range list {
+		idx.byID[c.ID] = i
+		idx.byNumber[c.Number] = i
+		for _, v := range []struct {
+			c   Concept
+			out *map[string]json.RawMessage
+		}{{c, &idx.fields[i]}, {forExam(c), &idx.exam[i]}} {
+			b, err := json.Marshal(v.c)
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(b, v.out); err != nil {
+				return nil, err
+			}
+		}
+		for f := range idx.fields[i] {
+			idx.known[f] = true
+		}
+		idx.text.Add(c.ID, c.Name, c.Description, c.Explanation, c.Example, c.UseCase)
+	}
+	b, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+	idx.all = newPrecompressed(b, "application/json")
+	return idx, nil
+}
+
+// conceptFilter holds the list query parameters. Every set field must match.
+type conceptFilter struct {
+	categories   []string // any of, case-insensitive
+	difficulties []string // any of, case-insensitive
+	prerequisite string   // concepts that list this ID as a prerequisite
+	text         string   // case-insensitive substring of the text fields
+}
+
+func parseConceptFilter(r *http.Request) conceptFilter {
+	q := r.URL.Query()
+	return conceptFilter{
+		categories:   splitList(q["category"]),
+		difficulties: splitList(q["difficulty"]),
+		prerequisite: strings.TrimSpace(q.Get("prerequisite")),
+		text:         strings.ToLower(strings.TrimSpace(q.Get("q"))),
+	}
+}
+
+// splitList flattens repeated and comma-separated query values.
+func splitList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+func (f conceptFilter) empty() bool {
+	return len(f.categories) == 0 && len(f.difficulties) == 0 && f.prerequisite == "" && f.text == ""
+}
+
+func (f conceptFilter) match(c *Concept) bool {
+	if len(f.categories) > 0 && !containsFold(f.categories, c.Category) {
+		return false
+	}
+	if len(f.difficulties) > 0 && !containsFold(f.difficulties, c.Difficulty) {
+		return false
+	}
+	if f.prerequisite != "" && !slices.Contains(c.Prerequisites, f.prerequisite) {
+		return false
+	}
+	if f.text != "" {
+		found := false
+		for _, s := range []string{c.ID, c.Name, c.Description, c.Instruction, c.Explanation, c.UseCase} {
+			if strings.Contains(strings.ToLower(s), f.text) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(list []string, s string) bool {
+	return slices.ContainsFunc(list, func(v string) bool { return strings.EqualFold(v, s) })
+}
+
+// selectFields validates ?fields= and returns the requested JSON field names,
+// or nil when the whole concept should be returned.
+func (idx *conceptIndex) selectFields(r *http.Request) ([]string, bool) {
+	fields := splitList(r.URL.Query()["fields"])
+	if len(fields) == 0 {
+		return nil, true
+	}
+	for _, f := range fields {
+		if !idx.known[f] {
+			return nil, false
+		}
+	}
+	return fields, true
+}
+
+// render returns concept i, restricted to fields when non-nil, and as
+// forExam shows it when it is in the learner's exam.
+func (idx *conceptIndex) render(i int, fields []string, inExam bool) any {
+	values := idx.fields[i]
+	if inExam {
+		values = idx.exam[i]
+	}
+	if fields == nil {
+		if inExam {
+			return forExam(idx.list[i])
+		}
+		return idx.list[i]
+	}
+	out := make(map[string]json.RawMessage, len(fields))
+	for _, f := range fields {
+		if v, ok := values[f]; ok {
+			out[f] = v
+		}
+	}
+	return out
+}
+
+func (idx *conceptIndex) fieldNames() string {
+	return strings.Join(sortedKeys(idx.known), ", ")
+}
+
+// listConcepts serves GET /api/concepts, optionally filtered by category, difficulty,
+// prerequisite and q, and projected with fields. Results are ordered by
+// concept number.
+func (idx *conceptIndex) listConcepts(w http.ResponseWriter, r *http.Request) {
+	filter := parseConceptFilter(r)
+	fields, ok := idx.selectFields(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "unknown field in fields; valid fields are: "+idx.fieldNames())
+		return
+	}
+	exam := idx.exams.activeConcepts(r)
+	if filter.empty() && fields == nil && exam == nil {
+		// The ETag keeps repeat loads to a 304.
+		idx.all.serve(w, r, conceptCache(w, exam))
+		return
+	}
+
+	out := make([]any, 0)
+	for i := range idx.list {
+		if filter.match(&idx.list[i]) {
+			out = append(out, idx.render(i, fields, exam[idx.list[i].ID]))
+		}
+	}
+	w.Header().Set("Cache-Control", conceptCache(w, exam))
+	writeJSON(w, http.StatusOK, out)
+}
+
+// getConcept serves GET /api/concepts/{id}.
+func (idx *conceptIndex) getConcept(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	i, ok := idx.byID[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "concept not found: "+id)
+		return
+	}
+	idx.writeOne(w, r, i)
+}
+
+// getConceptByNumber serves GET /api/concepts/by-number/{n}.
+func (idx *conceptIndex) getConceptByNumber(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "concept number must be an integer")
+		return
+	}
+	i, ok := idx.byNumber[n]
+	if !ok {
+		writeError(w, http.StatusNotFound, "concept not found: "+strconv.Itoa(n))
+		return
+	}
+	idx.writeOne(w, r, i)
+}
+
+func (idx *conceptIndex) writeOne(w http.ResponseWriter, r *http.Request, i int) {
+	fields, ok := idx.selectFields(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "unknown field in fields; valid fields are: "+idx.fieldNames())
+		return
+	}
+	exam := idx.exams.activeConcepts(r)
+	w.Header().Set("Cache-Control", conceptCache(w, exam))
+	writeJSON(w, http.StatusOK, idx.render(i, fields, exam[idx.list[i].ID]))
+}
+
+// conceptCache returns the Cache-Control for concept responses and marks
+// them as varying by cookie. What a learner may see changes the moment they
+// start an exam, so caches must revalidate every time, and responses
+// rendered for an exam are not stored at all.
+func conceptCache(w http.ResponseWriter, exam map[string]bool) string {
+	w.Header().Add("Vary", "Cookie")
+	if exam != nil {
+		return "private, no-store"
+	}
+	return "private, no-cache"
+}
+
+type searchHit struct {
+	ID         string           `json:"id"`
+	Number     int              `json:"number"`
+	Name       string           `json:"name"`
+	Category   string           `json:"category"`
+	Difficulty string           `json:"difficulty"`
+	Score      float64          `json:"score"`
+	Field      string           `json:"field"`
+	Snippet    []search.Segment `json:"snippet"`
+}
+
+// searchConcepts serves GET /api/search?q=&limit=, ranking concepts by BM25
+// over their names, descriptions, explanations, examples and use cases.
+func (idx *conceptIndex) searchConcepts(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		writeError(w, http.StatusBadRequest, "missing q parameter")
+		return
+	}
+	limit := defaultSearchLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(n, maxSearchLimit)
+	}
+
+	results := idx.text.Search(q, limit)
+	hits := make([]searchHit, len(results))
+	for i, res := range results {
+		c := idx.list[idx.byID[res.ID]]
+		hits[i] = searchHit{
+			ID:         c.ID,
+			Number:     c.Number,
+			Name:       c.Name,
+			Category:   c.Category,
+			Difficulty: c.Difficulty,
+			Score:      res.Score,
+			Field:      res.Field,
+			Snippet:    res.Snippet,
+		}
+	}
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, http.StatusOK, map[string]any{"query": q, "results": hits})
+}
+
+type staleConcept struct {
+	ID             string `json:"id"`
+	LearnedVersion string `json:"learnedVersion"`
+	CurrentVersion string `json:"currentVersion"`
+}
+
+// checkProgress serves POST /api/progress/check. The body maps learned
+// concept IDs to the content version they were earned against; the response
+// lists concepts whose content has since changed materially (so the learner
+// must re-verify them) and IDs that no longer exist.
+func (idx *conceptIndex) checkProgress(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Learned map[string]string `json:"learned"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	stale := make([]staleConcept, 0)
+	unknown := make([]string, 0)
+	for _, id := range sortedKeys(body.Learned) {
+		i, ok := idx.byID[id]
+		if !ok {
+			unknown = append(unknown, id)
+			continue
+		}
+		// Records from before versioning carry no version; they are adopted
+		// as current rather than flagged.
+		if v := body.Learned[id]; v != "" && v != idx.list[i].Version {
+			stale = append(stale, staleConcept{ID: id, LearnedVersion: v, CurrentVersion: idx.list[i].Version})
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"stale": stale, "unknown": unknown})
+}
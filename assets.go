@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"html/template"
+	"io"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// embedded holds the UI so the binary runs from any working directory. The
+// Docker build copies yaegi.wasm and wasm_exec.js into static/ before
+// compiling so they are embedded too.
+//
+//go:embed templates static
+var embedded embed.FS
+
+const assetHashLen = 12
+
+// assets serves the index template and static files either from the embedded
+// copy (production) or from the working directory (dev mode, for live
+// editing without rebuilding).
+type assets struct {
+	root   fs.FS // contains templates/ and static/
+	static fs.FS
+	dev    bool
+
+	tmpl    *template.Template // all templates/*.html, parsed once unless dev
+	hashes  map[string]string  // static-relative path -> content hash prefix
+	gzipped map[string][]byte  // gzip copies of compressible files without a .gz sibling
+}
+
+func newAssets(dev bool) (*assets, error) {
+	var root fs.FS = embedded
+	if dev {
+		root = os.DirFS(".")
+	}
+	static, err := fs.Sub(root, "static")
+	if err != nil {
+		return nil, err
+	}
+	a := &assets{root: root, static: static, dev: dev}
+	if dev {
+		// Hashes would go stale on every edit; serve unversioned URLs instead.
+		return a, nil
+	}
+	if a.hashes, err = hashFiles(static); err != nil {
+		return nil, err
+	}
+	if a.tmpl, err = a.parseTemplates(); err != nil {
+		return nil, err
+	}
+	if a.gzipped, err = gzipFiles(static, a.hashes); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// hashFiles computes a content hash for every file under fsys.
+func hashFiles(fsys fs.FS) (map[string]string, error) {
+	hashes := make(map[string]string)
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(b)
+		hashes[p] = hex.EncodeToString(sum[:])[:assetHashLen]
+		return nil
+	})
+	return hashes, err
+}
+
+// gzipFiles compresses every compressible file that does not already ship a
+// precompressed .gz sibling. It only runs in production, where the embedded
+// files cannot change.
+func gzipFiles(fsys fs.FS, hashes map[string]string) (map[string][]byte, error) {
+	out := make(map[string][]byte)
+	for name := range hashes {
+		if _, ok := hashes[name+".gz"]; ok || !compressible(name) {
+			continue
+		}
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		if gz := gzipBytes(b); len(gz) < len(b) {
+			out[name] = gz
+		}
+	}
+	return out, nil
+}
+
+func (a *assets) parseTemplates() (*template.Template, error) {
+	return template.New("").
+		Funcs(template.FuncMap{"asset": a.url}).
+		ParseFS(a.root, "templates/*.html")
+}
+
+// templates returns the page templates, re-reading them from disk in dev mode.
+func (a *assets) templates() (*template.Template, error) {
+	if a.dev {
+		return a.parseTemplates()
+	}
+	return a.tmpl, nil
+}
+
+// render executes the named page template, logging and answering 500 on
+// failure.
+func (a *assets) render(w http.ResponseWriter, r *http.Request, name string, data any) {
+	tmpl, err := a.templates()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "template parse failed", "err", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	// Pages reference content-hashed assets, so they must always be
+	// revalidated to pick up new hashes after a deploy.
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := tmpl.ExecuteTemplate(w, name, data); err != nil {
+		slog.ErrorContext(r.Context(), "template execute failed", "template", name, "err", err)
+	}
+}
+
+// url returns the public URL for a static file, versioned with its content
+// hash so it can be cached indefinitely and is refetched when it changes.
+func (a *assets) url(name string) string {
+	u := "/static/" + name
+	if h, ok := a.hashes[name]; ok {
+		u += "?v=" + h
+	}
+	return u
+}
+
+// serveStatic serves files under /static/. Requests whose ?v= matches the
+// current content hash are immutable; anything else must revalidate against
+// the ETag. Precompressed .br/.gz siblings are preferred when the client
+// accepts them; other compressible files get a gzip copy built at startup.
+func (a *assets) serveStatic() http.Handler {
+	return http.StripPrefix("/static/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "" || strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		fi, err := fs.Stat(a.static, name)
+		if err != nil || fi.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+
+		h := w.Header()
+		if v := r.URL.Query().Get("v"); v != "" && v == a.hashes[name] {
+			h.Set("Cache-Control", "public, max-age=31536000, immutable")
+		} else {
+			h.Set("Cache-Control", "no-cache")
+		}
+		ctype := mime.TypeByExtension(path.Ext(name))
+		if ctype == "" {
+			ctype = "application/octet-stream"
+		}
+		h.Set("Content-Type", ctype)
+		h.Add("Vary", "Accept-Encoding")
+
+		accept := r.Header.Get("Accept-Encoding")
+		for _, enc := range encodings {
+			if !acceptsEncoding(accept, enc.name) {
+				continue
+			}
+			if f, err := a.static.Open(name + enc.ext); err == nil {
+				defer f.Close()
+				efi, _ := f.Stat()
+				h.Set("Content-Encoding", enc.name)
+				a.setETag(h, name+enc.ext)
+				http.ServeContent(w, r, name, efi.ModTime(), f.(io.ReadSeeker))
+				return
+			}
+			if gz, ok := a.gzipped[name]; ok && enc.name == "gzip" {
+				h.Set("Content-Encoding", "gzip")
+				h.Set("ETag", `"`+a.hashes[name]+`-gzip"`)
+				http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(gz))
+				return
+			}
+		}
+
+		f, err := a.static.Open(name)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer f.Close()
+		a.setETag(h, name)
+		http.ServeContent(w, r, name, fi.ModTime(), f.(io.ReadSeeker))
+	}))
+}
+
+// setETag sets a strong ETag derived from the file's content hash. In dev
+// mode there are no hashes and ServeContent falls back to Last-Modified.
+func (a *assets) setETag(h http.Header, name string) {
+	if hash, ok := a.hashes[name]; ok {
+		h.Set("ETag", `"`+hash+`"`)
+	}
+}
@@ -0,0 +1,134 @@
+// Package cheatsheet renders the concept catalogue as a printable reference
+// in Markdown, HTML or PDF, grouped by category in the order the trainer UI
+// lists them.
+package cheatsheet
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+
+	"go-concept-trainer/concepts"
+)
+
+// DefaultTitle is used when callers have no better title.
+const DefaultTitle = "Clanker Rehab: Go Cheat Sheet"
+
+// Section is one category and its concepts in number order.
+type Section struct {
+	Category string
+	Concepts []concepts.Concept
+}
+
+// Group sorts concepts by number and groups them by category, ordering
+// categories by their lowest concept number as the UI does. When categories
+// is non-empty only those (matched case-insensitively) are kept.
+func Group(all []concepts.Concept, categories ...string) []Section {
+	sorted := slices.Clone(all)
+	slices.SortStableFunc(sorted, func(a, b concepts.Concept) int { return a.Number - b.Number })
+
+	var sections []Section
+	pos := make(map[string]int)
+	for _, c := range sorted {
+		if len(categories) > 0 && !slices.ContainsFunc(categories, func(s string) bool { return strings.EqualFold(s, c.Category) }) {
+			continue
+		}
+		i, ok := pos[c.Category]
+		if !ok {
+			i = len(sections)
+			pos[c.Category] = i
+			sections = append(sections, Section{Category: c.Category})
+		}
+		sections[i].Concepts = append(sections[i].Concepts, c)
+	}
+	return sections
+}
+
+// Markdown writes the sheet as GitHub-flavoured Markdown.
+func Markdown(w io.Writer, title string, sections []Section) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	for _, s := range sections {
+		fmt.Fprintf(&b, "- [%s](#%s) (%d)\n", s.Category, anchor(s.Category), len(s.Concepts))
+	}
+	for _, s := range sections {
+		fmt.Fprintf(&b, "\n## %s\n", s.Category)
+		for _, c := range s.Concepts {
+			fmt.Fprintf(&b, "\n### %s\n\n*%s* · %s\n", c.Name, c.Difficulty, c.Description)
+			if c.Example != "" {
+				fmt.Fprintf(&b, "\n```go\n%s\n```\n", strings.TrimRight(c.Example, "\n"))
+			}
+			if c.DocsURL != "" {
+				fmt.Fprintf(&b, "\n[Go documentation](%s)\n", c.DocsURL)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// anchor mimics GitHub's heading anchors: lowercase, punctuation dropped,
+// spaces to hyphens.
+func anchor(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r == ' ':
+			b.WriteByte('-')
+		case r == '-' || r == '_' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var htmlTmpl = template.Must(template.New("cheatsheet").Funcs(template.FuncMap{"anchor": anchor}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; font-size: 10pt; color: #111; max-width: 60rem; margin: 1.5rem auto; padding: 0 1rem; }
+h1 { font-size: 18pt; }
+h2 { font-size: 13pt; border-bottom: 1px solid #999; margin-top: 1.5rem; }
+.concept { break-inside: avoid; margin: 0.6rem 0; }
+.concept h3 { font-size: 10.5pt; margin: 0; }
+.meta { color: #555; margin: 0.15rem 0; }
+pre { font-family: Menlo, Consolas, 'DejaVu Sans Mono', monospace; font-size: 8.5pt; background: #f4f4f4; padding: 0.4rem; margin: 0.3rem 0; white-space: pre-wrap; }
+a { color: #0645ad; }
+nav ul { columns: 2; }
+@media print {
+  body { margin: 0; max-width: none; }
+  nav { display: none; }
+  h2 { break-after: avoid; }
+  a { color: inherit; text-decoration: none; }
+  a[href^="http"]::after { content: " (" attr(href) ")"; font-size: 8pt; color: #555; }
+}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<nav><ul>{{range .Sections}}<li><a href="#{{anchor .Category}}">{{.Category}}</a> ({{len .Concepts}})</li>{{end}}</ul></nav>
+{{range .Sections}}
+<h2 id="{{anchor .Category}}">{{.Category}}</h2>
+{{range .Concepts}}<div class="concept">
+<h3>{{.Name}}</h3>
+<p class="meta"><em>{{.Difficulty}}</em> · {{.Description}}</p>
+{{with .Example}}<pre><code>{{.}}</code></pre>{{end}}
+{{with .DocsURL}}<a href="{{.}}">Go documentation</a>{{end}}
+</div>
+{{end}}{{end}}
+</body>
+</html>
+`))
+
+// HTML writes a standalone, print-friendly HTML page. Printing it from a
+// browser gives a paginated PDF with link targets spelled out.
+func HTML(w io.Writer, title string, sections []Section) error {
+	return htmlTmpl.Execute(w, struct {
+		Title    string
+		Sections []Section
+	}{title, sections})
+}
@@ -0,0 +1,177 @@
+package cheatsheet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// The PDF is text only, set in the standard Courier fonts every PDF reader
+// ships with. Courier is monospaced (600/1000 em per glyph), which makes
+// line wrapping exact without font metrics.
+const (
+	pdfPageW   = 595 // A4 in points
+	pdfPageH   = 842
+	pdfMargin  = 48
+	pdfGlyphEm = 0.6
+)
+
+type pdfLine struct {
+	font   string // F1 Courier, F2 Courier-Bold
+	size   float64
+	indent float64
+	text   string
+	gap    float64 // extra space before the line
+}
+
+// PDF writes the sheet as a paginated A4 PDF.
+func PDF(w io.Writer, title string, sections []Section) error {
+	var lines []pdfLine
+	add := func(font string, size, indent, gap float64, text string) {
+		width := int((pdfPageW - 2*pdfMargin - indent) / (size * pdfGlyphEm))
+		for i, l := range wrap(text, width) {
+			g := gap
+			if i > 0 {
+				g = 0
+			}
+			lines = append(lines, pdfLine{font, size, indent, l, g})
+		}
+	}
+
+	add("F2", 16, 0, 0, title)
+	for _, s := range sections {
+		add("F2", 13, 0, 14, s.Category)
+		for _, c := range s.Concepts {
+			add("F2", 10, 0, 8, c.Name)
+			add("F1", 9, 0, 1, c.Difficulty+" - "+c.Description)
+			for _, l := range strings.Split(strings.TrimRight(c.Example, "\n"), "\n") {
+				if c.Example != "" {
+					add("F1", 8.5, 14, 0, strings.ReplaceAll(l, "\t", "    "))
+				}
+			}
+			if c.DocsURL != "" {
+				add("F1", 8, 0, 1, "Docs: "+c.DocsURL)
+			}
+		}
+	}
+
+	// Paginate, keeping a heading with at least a few following lines.
+	var pages [][]pdfLine
+	var page []pdfLine
+	y := float64(pdfPageH - pdfMargin)
+	for i, l := range lines {
+		need := l.gap + l.size*1.25
+		if l.font == "F2" && l.size >= 13 {
+			need += 4 * 11
+		}
+		if y-need < pdfMargin && len(page) > 0 {
+			pages = append(pages, page)
+			page, y = nil, pdfPageH-pdfMargin
+			lines[i].gap = 0
+			l.gap = 0
+		}
+		y -= l.gap + l.size*1.25
+		page = append(page, l)
+	}
+	if len(page) > 0 {
+		pages = append(pages, page)
+	}
+
+	return writePDF(w, pages)
+}
+
+func writePDF(w io.Writer, pages [][]pdfLine) error {
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// Fixed objects: 1 catalog, 2 page tree, 3-4 fonts. Pages and their
+	// content streams follow in pairs.
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range pages {
+		var cs bytes.Buffer
+		y := float64(pdfPageH - pdfMargin)
+		for _, l := range page {
+			y -= l.gap + l.size*1.25
+			fmt.Fprintf(&cs, "BT /%s %g Tf %g %g Td (%s) Tj ET\n", l.font, l.size, pdfMargin+l.indent, y, pdfString(l.text))
+		}
+		fmt.Fprintf(&cs, "BT /F1 7 Tf %d %d Td (%d / %d) Tj ET\n", pdfPageW/2-10, pdfMargin/2, i+1, len(pages))
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageW, pdfPageH, 6+2*i))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", cs.Len(), cs.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// wrap breaks s into lines of at most width runes, preferring spaces and
+// hard-breaking long words such as URLs.
+func wrap(s string, width int) []string {
+	if width < 1 {
+		width = 1
+	}
+	var lines []string
+	for utf8.RuneCountInString(s) > width {
+		runes := []rune(s)
+		cut := width
+		for i := width; i > width/2; i-- {
+			if runes[i] == ' ' {
+				cut = i
+				break
+			}
+		}
+		lines = append(lines, strings.TrimRight(string(runes[:cut]), " "))
+		s = string(runes[cut:])
+		if strings.HasPrefix(s, " ") {
+			s = s[1:]
+		}
+	}
+	return append(lines, s)
+}
+
+var pdfReplacements = strings.NewReplacer(
+	"→", "->", "←", "<-", "…", "...", "·", "-", "—", "--", "–", "-",
+	"‘", "'", "’", "'", "“", `"`, "”", `"`, "≤", "<=", "≥", ">=", "≠", "!=",
+)
+
+// pdfString escapes s for a PDF literal string in WinAnsiEncoding, replacing
+// characters the standard fonts cannot show.
+func pdfString(s string) string {
+	s = pdfReplacements.Replace(s)
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
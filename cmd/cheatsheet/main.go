@@ -0,0 +1,63 @@
+// Command cheatsheet renders the concept catalogue as a printable cheat
+// sheet.
+//
+// Usage:
+//
+//	cheatsheet [-format md|html|pdf] [-category name[,name...]] [-o file]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go-concept-trainer/cheatsheet"
+	"go-concept-trainer/concepts"
+)
+
+func main() {
+	format := flag.String("format", "md", "output format: md, html or pdf")
+	category := flag.String("category", "", "comma-separated categories to include (default: all)")
+	title := flag.String("title", cheatsheet.DefaultTitle, "document title")
+	out := flag.String("o", "", "write to this file instead of stdout")
+	flag.Parse()
+
+	var cats []string
+	for _, c := range strings.Split(*category, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			cats = append(cats, c)
+		}
+	}
+	sections := cheatsheet.Group(concepts.GetAll(), cats...)
+	if len(sections) == 0 {
+		fmt.Fprintf(os.Stderr, "cheatsheet: no concepts in category %q\n", *category)
+		os.Exit(1)
+	}
+
+	render := map[string]func(io.Writer, string, []cheatsheet.Section) error{
+		"md":   cheatsheet.Markdown,
+		"html": cheatsheet.HTML,
+		"pdf":  cheatsheet.PDF,
+	}[*format]
+	if render == nil {
+		fmt.Fprintf(os.Stderr, "cheatsheet: unknown format %q (want md, html or pdf)\n", *format)
+		os.Exit(2)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cheatsheet: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := render(w, *title, sections); err != nil {
+		fmt.Fprintf(os.Stderr, "cheatsheet: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,297 @@
+// Command difficulty-report compares each concept's declared difficulty with
+// how learners actually fare on it, using the code_execute events the server
+// logs for /api/log-run, and lists the concepts that look mislabelled.
+//
+// Usage:
+//
+//	difficulty-report [-min-attempts n] [-all] [-json] log-file-or-dir...
+//
+// Directories are searched for the server's daily log files (-log-dir).
+// Only the JSON log format is read. Runs carry a learner pseudonym only when
+// the server has a stable learner key (LOG_LEARNER_SECRET or -data-dir);
+// runs without one are skipped.
+//
+// Runs are grouped by learner pseudonym and concept into attempts, each
+// ending with a passing run; an attempt still open at the end of the logs
+// counts as not passed. Per concept the report gives the pass rate (share
+// of attempts that passed), runs to pass (mean runs in the attempts that
+// passed) and reveal rate (share of attempts in which the answer was
+// shown). These combine into a score from 0 (easy) to 1 (hard), and the
+// concept's empirical difficulty is the label whose typical score is
+// nearest: the median score of the concepts declared with that label, or a
+// fixed default when too few of them have data.
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"go-concept-trainer/concepts"
+)
+
+var levels = []string{"beginner", "intermediate", "advanced"}
+
+// defaultCentres are the typical scores used for a label with too few
+// concepts to measure.
+var defaultCentres = map[string]float64{"beginner": 0.15, "intermediate": 0.4, "advanced": 0.65}
+
+const (
+	// minCentreConcepts is how many concepts of a label need data before
+	// their median replaces the default centre.
+	minCentreConcepts = 3
+	// maxExtraRuns is the number of failed runs before a pass that scores
+	// as hard as never passing.
+	maxExtraRuns = 5
+)
+
+// run is one code_execute log event.
+type run struct {
+	Time     time.Time `json:"time"`
+	Msg      string    `json:"msg"`
+	Concept  string    `json:"concept"`
+	Outcome  string    `json:"outcome"`
+	Revealed bool      `json:"revealed"`
+	Learner  string    `json:"learner"`
+}
+
+// stats is the empirical difficulty of one concept.
+type stats struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Declared   string  `json:"declared"`
+	Empirical  string  `json:"empirical"`
+	Attempts   int     `json:"attempts"`
+	PassRate   float64 `json:"passRate"`
+	RunsToPass float64 `json:"runsToPass"` // 0 when no attempt passed
+	RevealRate float64 `json:"revealRate"`
+	Score      float64 `json:"score"`
+	Gap        float64 `json:"gap"` // distance from the declared label's centre
+}
+
+func main() {
+	minAttempts := flag.Int("min-attempts", 10, "ignore concepts with fewer attempts than this")
+	all := flag.Bool("all", false, "list every concept with enough attempts, not just mislabelled ones")
+	asJSON := flag.Bool("json", false, "write JSON instead of a table")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: difficulty-report [-min-attempts n] [-all] [-json] log-file-or-dir...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var runs []run
+	var skipped int
+	for _, arg := range flag.Args() {
+		files, err := logFiles(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "difficulty-report: %v\n", err)
+			os.Exit(1)
+		}
+		for _, name := range files {
+			n, err := readRuns(name, &runs)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "difficulty-report: %s: %v\n", name, err)
+				os.Exit(1)
+			}
+			skipped += n
+		}
+	}
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "difficulty-report: skipped %d runs without a learner pseudonym\n", skipped)
+	}
+
+	report := measure(concepts.GetAll(), runs, *minAttempts)
+	if !*all {
+		report = slices.DeleteFunc(report, func(s stats) bool { return s.Empirical == s.Declared })
+	}
+	slices.SortStableFunc(report, func(a, b stats) int { return cmp.Compare(b.Gap, a.Gap) })
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if report == nil {
+			report = []stats{}
+		}
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "difficulty-report: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	writeTable(os.Stdout, report)
+}
+
+// logFiles expands a directory into its daily log files.
+func logFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	files, err := filepath.Glob(filepath.Join(path, "server-*.log"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// readRuns appends the runs logged with a concept in name to runs and
+// returns how many it skipped for lacking a learner pseudonym. Lines that
+// are not JSON events are ignored.
+func readRuns(name string, runs *[]run) (skipped int, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		var r run
+		if json.Unmarshal(sc.Bytes(), &r) != nil || r.Msg != "code_execute" || r.Concept == "" {
+			continue
+		}
+		if r.Learner == "" {
+			skipped++
+			continue
+		}
+		*runs = append(*runs, r)
+	}
+	return skipped, sc.Err()
+}
+
+// attempt is a learner's runs on a concept up to and including a pass.
+type attempt struct {
+	runs     int
+	passed   bool
+	revealed bool
+}
+
+// measure computes stats for the concepts with at least minAttempts
+// attempts, in curriculum order.
+func measure(all []concepts.Concept, runs []run, minAttempts int) []stats {
+	slices.SortStableFunc(runs, func(a, b run) int { return a.Time.Compare(b.Time) })
+	open := make(map[[2]string]*attempt)
+	done := make(map[string][]attempt)
+	for _, r := range runs {
+		key := [2]string{r.Learner, r.Concept}
+		a := open[key]
+		if a == nil {
+			a = &attempt{}
+			open[key] = a
+		}
+		a.runs++
+		a.revealed = a.revealed || r.Revealed
+		if r.Outcome == "pass" {
+			a.passed = true
+			done[r.Concept] = append(done[r.Concept], *a)
+			delete(open, key)
+		}
+	}
+	for key, a := range open {
+		done[key[1]] = append(done[key[1]], *a)
+	}
+
+	var out []stats
+	for _, c := range all {
+		attempts := done[c.ID]
+		if len(attempts) < max(1, minAttempts) {
+			continue
+		}
+		s := stats{ID: c.ID, Name: c.Name, Declared: c.Difficulty, Attempts: len(attempts)}
+		var passed, revealed, passRuns int
+		for _, a := range attempts {
+			if a.passed {
+				passed++
+				passRuns += a.runs
+			}
+			if a.revealed {
+				revealed++
+			}
+		}
+		s.PassRate = float64(passed) / float64(len(attempts))
+		s.RevealRate = float64(revealed) / float64(len(attempts))
+		extra := 1.0
+		if passed > 0 {
+			s.RunsToPass = float64(passRuns) / float64(passed)
+			extra = min(1, (s.RunsToPass-1)/maxExtraRuns)
+		}
+		s.Score = 0.4*(1-s.PassRate) + 0.3*extra + 0.3*s.RevealRate
+		out = append(out, s)
+	}
+
+	centres := labelCentres(out)
+	for i := range out {
+		s := &out[i]
+		best := math.Inf(1)
+		for _, l := range levels {
+			if d := math.Abs(s.Score - centres[l]); d < best {
+				best, s.Empirical = d, l
+			}
+		}
+		if c, ok := centres[s.Declared]; ok {
+			s.Gap = math.Abs(s.Score - c)
+		}
+	}
+	return out
+}
+
+// labelCentres is the median score per declared label, falling back to
+// the defaults for labels with too little data or when the medians are not
+// in order of difficulty.
+func labelCentres(measured []stats) map[string]float64 {
+	centres := make(map[string]float64, len(levels))
+	for _, l := range levels {
+		var scores []float64
+		for _, s := range measured {
+			if s.Declared == l {
+				scores = append(scores, s.Score)
+			}
+		}
+		centres[l] = defaultCentres[l]
+		if len(scores) >= minCentreConcepts {
+			slices.Sort(scores)
+			centres[l] = (scores[(len(scores)-1)/2] + scores[len(scores)/2]) / 2
+		}
+	}
+	for i := 1; i < len(levels); i++ {
+		if centres[levels[i]] <= centres[levels[i-1]] {
+			return defaultCentres
+		}
+	}
+	return centres
+}
+
+func writeTable(w io.Writer, report []stats) {
+	if len(report) == 0 {
+		fmt.Fprintln(w, "No mislabelled concepts among those with enough attempts.")
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONCEPT\tDECLARED\tEMPIRICAL\tSCORE\tPASS\tRUNS\tREVEAL\tATTEMPTS")
+	for _, s := range report {
+		runs := "-"
+		if s.RunsToPass > 0 {
+			runs = fmt.Sprintf("%.1f", s.RunsToPass)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.2f\t%.0f%%\t%s\t%.0f%%\t%d\n",
+			s.ID, s.Declared, s.Empirical, s.Score, s.PassRate*100, runs, s.RevealRate*100, s.Attempts)
+	}
+	tw.Flush()
+}
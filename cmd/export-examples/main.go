@@ -0,0 +1,135 @@
+// Command export-examples writes every concept's reference Answer as a Go
+// Example function whose "// Output:" comment is the concept's
+// ExpectedOutput, so the real toolchain can check the whole curriculum:
+//
+//	go run ./cmd/export-examples
+//	go test ./curriculumtest/...
+//
+// Each concept gets its own package (curriculumtest/c001, c002, ...) because
+// answers are whole programs whose helper names would otherwise collide.
+// Predict-the-output concepts have no authored output, so their examples
+// are compiled but not run.
+// The output is generated and ignored by git; the previous run's packages
+// are replaced on each run.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-concept-trainer/concepts"
+)
+
+const header = "// Code generated by export-examples from concept %q. DO NOT EDIT.\n\n"
+
+func main() {
+	out := flag.String("o", "curriculumtest", "directory to write the test packages to")
+	flag.Parse()
+
+	stale, err := filepath.Glob(filepath.Join(*out, "c[0-9][0-9][0-9]*"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export-examples: %v\n", err)
+		os.Exit(1)
+	}
+	for _, dir := range stale {
+		if err := os.RemoveAll(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "export-examples: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	failed := 0
+	all := concepts.GetAll()
+	for _, c := range all {
+		pkg := fmt.Sprintf("c%03d", c.Number)
+		src, err := exampleSource(pkg, c)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "export-examples: %s: %v\n", c.ID, err)
+			failed++
+			continue
+		}
+		dir := filepath.Join(*out, pkg)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			fmt.Fprintf(os.Stderr, "export-examples: %v\n", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "example_test.go"), src, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "export-examples: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d example packages to %s\n", len(all)-failed, *out)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// exampleSource turns a concept's answer program into a test file in package
+// pkg: main becomes Example, and the expected output is appended as the
+// example's output comment.
+func exampleSource(pkg string, c concepts.Concept) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "answer.go", c.Answer, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("answer does not parse: %v", err)
+	}
+	if f.Name.Name != "main" {
+		return nil, fmt.Errorf("answer is package %s, want main", f.Name.Name)
+	}
+	f.Name.Name = pkg
+
+	var example *ast.FuncDecl
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "main" {
+			example = fn
+		}
+	}
+	if example == nil {
+		return nil, fmt.Errorf("answer has no func main")
+	}
+	example.Name.Name = "Example"
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, f); err != nil {
+		return nil, err
+	}
+
+	// Insert the output comment before Example's closing brace. Positions
+	// in the printed source differ from the original, so find the brace by
+	// parsing the printed file again.
+	src := buf.Bytes()
+	printed, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return nil, err
+	}
+	var rbrace int
+	for _, decl := range printed.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "Example" {
+			rbrace = int(fn.Body.Rbrace) - int(printed.FileStart)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, header, c.ID)
+	if c.Kind == concepts.KindPredict {
+		out.Write(src)
+		return format.Source(out.Bytes())
+	}
+	out.Write(src[:rbrace])
+	out.WriteString("\t// Output:\n")
+	if expected := strings.TrimSpace(c.ExpectedOutput); expected != "" {
+		for _, line := range strings.Split(expected, "\n") {
+			out.WriteString(strings.TrimRight("\t// "+line, " ") + "\n")
+		}
+	}
+	out.Write(src[rbrace:])
+	return format.Source(out.Bytes())
+}
@@ -0,0 +1,417 @@
+// Command import-examples drafts concepts from the runnable Example
+// functions of a local Go package.
+//
+// Usage:
+//
+//	import-examples [-o dir] [-category name] [-difficulty level] [-start n] [-f] ./path/to/pkg
+//
+// Each example that go/doc can turn into a standalone program and that has an
+// "// Output:" comment becomes one concept file in the concepts package
+// format: the program is the Answer, the output comment the ExpectedOutput,
+// the example's doc comment the Explanation, and the Boilerplate is the same
+// program with main's body replaced by a placeholder. Examples without
+// output, with unordered output, or that only make sense inside the package
+// are skipped with a note on stderr.
+//
+// The files are drafts for review: instructions and use cases need a human,
+// and each new ConceptNNN must be added to concepts/loader.go.
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go-concept-trainer/concepts"
+)
+
+func main() {
+	out := flag.String("o", "concepts", "directory to write concept files to")
+	category := flag.String("category", "Standard Library", "category for the imported concepts")
+	difficulty := flag.String("difficulty", "intermediate", "difficulty for the imported concepts")
+	start := flag.Int("start", 0, "number of the first concept (default: after the highest registered)")
+	force := flag.Bool("f", false, "overwrite existing files")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: import-examples [flags] package-dir\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := flag.Arg(0)
+
+	drafts, err := loadExamples(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import-examples: %v\n", err)
+		os.Exit(1)
+	}
+	if len(drafts) == 0 {
+		fmt.Fprintf(os.Stderr, "import-examples: no runnable examples with output in %s\n", dir)
+		os.Exit(1)
+	}
+
+	number := *start
+	known := make(map[string]bool)
+	for _, c := range concepts.GetAll() {
+		number = max(number, c.Number+1)
+		known[c.ID] = true
+	}
+	if *start > 0 {
+		number = *start
+	}
+	importPath := goListImportPath(dir)
+
+	var vars []string
+	for _, d := range drafts {
+		c := d.concept(number, *category, *difficulty, importPath)
+		if known[c.ID] {
+			fmt.Fprintf(os.Stderr, "import-examples: skipping %s: concept ID %q already exists\n", d.name, c.ID)
+			continue
+		}
+		src, err := conceptSource(c)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "import-examples: %s: %v\n", d.name, err)
+			os.Exit(1)
+		}
+		path := filepath.Join(*out, fmt.Sprintf("%03d_%s.go", c.Number, c.ID))
+		if !*force {
+			if _, err := os.Stat(path); err == nil {
+				fmt.Fprintf(os.Stderr, "import-examples: %s exists (use -f to overwrite)\n", path)
+				os.Exit(1)
+			}
+		}
+		if err := os.WriteFile(path, src, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "import-examples: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(path)
+		vars = append(vars, fmt.Sprintf("Concept%03d", c.Number))
+		number++
+	}
+	if len(vars) > 0 {
+		fmt.Fprintf(os.Stderr, "add to concepts/loader.go: %s,\n", strings.Join(vars, ", "))
+	}
+}
+
+// draft is a runnable example pulled out of a package's tests.
+type draft struct {
+	pkg     string // package name without the _test suffix
+	name    string // e.g. "ExampleBuffer_Grow"
+	doc     string
+	program string // whole runnable program
+	body    string // statements of the example function
+	output  string
+
+	example *doc.Example
+	fset    *token.FileSet
+}
+
+// loadExamples parses the _test.go files in dir and returns the examples
+// go/doc can run as standalone programs, in source order.
+func loadExamples(dir string) ([]draft, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no test files in %s", dir)
+	}
+	slices.Sort(paths)
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, p := range paths {
+		f, err := parser.ParseFile(fset, p, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	var drafts []draft
+	for _, ex := range doc.Examples(files...) {
+		name := "Example" + ex.Name
+		switch {
+		case ex.Output == "" && !ex.EmptyOutput:
+			fmt.Fprintf(os.Stderr, "import-examples: skipping %s: no // Output: comment\n", name)
+			continue
+		case ex.Unordered:
+			fmt.Fprintf(os.Stderr, "import-examples: skipping %s: unordered output cannot be checked\n", name)
+			continue
+		case ex.Play == nil:
+			fmt.Fprintf(os.Stderr, "import-examples: skipping %s: not runnable outside its package\n", name)
+			continue
+		}
+		program, err := formatNode(fset, trimOutputGap(ex))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		body, err := formatBody(fset, ex)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		drafts = append(drafts, draft{
+			pkg:     strings.TrimSuffix(files[0].Name.Name, "_test"),
+			name:    name,
+			doc:     strings.TrimSpace(ex.Doc),
+			program: program,
+			body:    body,
+			output:  strings.TrimSpace(ex.Output),
+			example: ex,
+			fset:    fset,
+		})
+	}
+	return drafts, nil
+}
+
+// concept fills in a concept from the example. Fields a generator cannot
+// write well are left as TODOs for the reviewer.
+func (d draft) concept(number int, category, difficulty, importPath string) concepts.Concept {
+	subject := d.subject()
+	description := firstSentence(d.doc)
+	if description == "" {
+		description = "Use " + subject
+	}
+	c := concepts.Concept{
+		Number:         number,
+		ID:             conceptID(d.pkg, cmp.Or(d.example.Name, "package"), d.example.Suffix),
+		Category:       category,
+		Name:           fmt.Sprintf("%d. %s", number, subject),
+		Description:    description,
+		Instruction:    "TODO: describe the task. Use " + subject + " to print the expected output.",
+		Boilerplate:    d.boilerplate(),
+		Answer:         d.program,
+		ExpectedOutput: d.output,
+		Difficulty:     difficulty,
+		Explanation:    cmp.Or(d.doc, "TODO"),
+		Example:        d.body,
+		UseCase:        "TODO",
+		Prerequisites:  []string{},
+		RelatedTopics:  []string{},
+	}
+	if importPath != "" {
+		anchor := "example-" + strings.TrimPrefix(strings.ReplaceAll(d.example.Name, "_", "-"), "-")
+		if d.example.Name == "" {
+			anchor = "example-package"
+		}
+		c.DocsURL = "https://pkg.go.dev/" + importPath + "#" + anchor
+	}
+	return c
+}
+
+// subject names what the example demonstrates: "bytes.Buffer.Grow" for
+// ExampleBuffer_Grow, "bytes" for the package example.
+func (d draft) subject() string {
+	name := strings.TrimSuffix(d.example.Name, "_"+d.example.Suffix)
+	if name == "" {
+		return d.pkg
+	}
+	return d.pkg + "." + strings.ReplaceAll(name, "_", ".")
+}
+
+// boilerplate is the runnable program with main's body replaced by the
+// placeholder the hand-written concepts use. Imports are kept so learners
+// see which packages are involved.
+func (d draft) boilerplate() string {
+	f := *d.example.Play
+	f.Decls = slices.Clone(f.Decls)
+	for i, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "main" || fn.Recv != nil {
+			continue
+		}
+		stub := *fn
+		stub.Body = &ast.BlockStmt{Lbrace: fn.Body.Lbrace, Rbrace: fn.Body.Rbrace}
+		f.Decls[i] = &stub
+	}
+	// Comments inside the stripped body would be printed out of place.
+	f.Comments = nil
+	src, err := formatNode(d.fset, &f)
+	if err != nil {
+		return d.program
+	}
+	return emptyMain.ReplaceAllString(src, "func main() {\n\t// Your code here\n}")
+}
+
+var outputComment = regexp.MustCompile(`(?m)^\s*// (Unordered output|Output):`)
+
+var emptyMain = regexp.MustCompile(`func main\(\) \{\s*\}`)
+
+// trimOutputGap returns ex.Play with main's closing brace moved up to the
+// end of whatever precedes the output comment, so the program does not end
+// in the blank lines go/doc leaves when it removes the comment. ex.Play is
+// not modified.
+func trimOutputGap(ex *doc.Example) *ast.File {
+	code, ok := ex.Code.(*ast.BlockStmt)
+	if !ok {
+		return ex.Play
+	}
+	var output *ast.CommentGroup
+	for _, cg := range ex.Comments {
+		if cg.Pos() > code.Lbrace && cg.End() < code.Rbrace {
+			output = cg
+		}
+	}
+	if output == nil || !outputComment.MatchString(output.List[0].Text) {
+		return ex.Play
+	}
+	end := code.Lbrace
+	for _, s := range code.List {
+		if s.End() <= output.Pos() {
+			end = max(end, s.End())
+		}
+	}
+	for _, cg := range ex.Comments {
+		if cg.Pos() > code.Lbrace && cg.End() < output.Pos() {
+			end = max(end, cg.End())
+		}
+	}
+	f := *ex.Play
+	f.Decls = slices.Clone(f.Decls)
+	for i, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "main" || fn.Recv != nil {
+			continue
+		}
+		body := *fn.Body
+		body.Rbrace = end
+		trimmed := *fn
+		trimmed.Body = &body
+		f.Decls[i] = &trimmed
+	}
+	return &f
+}
+
+func formatNode(fset *token.FileSet, node any) (string, error) {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, node); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// formatBody prints an example's body with its comments, without braces and
+// dedented one level.
+func formatBody(fset *token.FileSet, ex *doc.Example) (string, error) {
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := cfg.Fprint(&buf, fset, &printer.CommentedNode{Node: ex.Code, Comments: ex.Comments}); err != nil {
+		return "", err
+	}
+	s := strings.TrimSpace(buf.String())
+	if _, ok := ex.Code.(*ast.BlockStmt); ok {
+		// The output comment is part of the body's comments; the
+		// concept carries it as ExpectedOutput instead.
+		if i := outputComment.FindStringIndex(s); i != nil {
+			s = s[:i[0]] + "}"
+		}
+		s = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}"))
+		lines := strings.Split(s, "\n")
+		for i, l := range lines {
+			lines[i] = strings.TrimPrefix(l, "\t")
+		}
+		s = strings.Join(lines, "\n")
+	}
+	return s, nil
+}
+
+// conceptID builds a kebab-case ID such as "bytes-buffer-grow".
+func conceptID(pkg, name, suffix string) string {
+	parts := []string{pkg}
+	for _, p := range strings.Split(strings.TrimSuffix(name, "_"+suffix), "_") {
+		if p != "" {
+			parts = append(parts, kebab(p))
+		}
+	}
+	if suffix != "" {
+		parts = append(parts, kebab(suffix))
+	}
+	return strings.ToLower(strings.Join(parts, "-"))
+}
+
+// kebab splits a camel-case identifier: "ReadAll" becomes "read-all".
+func kebab(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			b.WriteByte('-')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+func firstSentence(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if i := strings.Index(s, ". "); i >= 0 {
+		return s[:i]
+	}
+	return strings.TrimSuffix(s, ".")
+}
+
+// goListImportPath asks the go tool for the package's import path, for the
+// pkg.go.dev link. It returns "" when that fails, e.g. outside a module.
+func goListImportPath(dir string) string {
+	cmd := exec.Command("go", "list", "-f", "{{.ImportPath}}", ".")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// conceptSource renders c in the layout of the hand-written concept files.
+func conceptSource(c concepts.Concept) ([]byte, error) {
+	var b bytes.Buffer
+	v := fmt.Sprintf("Concept%03d", c.Number)
+	fmt.Fprintf(&b, "package concepts\n\n// %03d. %s\n// Imported from Example functions; review before committing.\nvar %s = Concept{\n", c.Number, strings.TrimPrefix(c.Name, strconv.Itoa(c.Number)+". "), v)
+	field := func(name, value string) { fmt.Fprintf(&b, "\t%s: %s,\n", name, goString(value)) }
+	fmt.Fprintf(&b, "\tNumber: %d,\n", c.Number)
+	field("ID", c.ID)
+	field("Category", c.Category)
+	field("Name", c.Name)
+	field("Description", c.Description)
+	field("Instruction", c.Instruction)
+	field("Boilerplate", c.Boilerplate)
+	field("Answer", c.Answer)
+	field("ExpectedOutput", c.ExpectedOutput)
+	field("Difficulty", c.Difficulty)
+	field("Explanation", c.Explanation)
+	field("Example", c.Example)
+	field("UseCase", c.UseCase)
+	fmt.Fprintf(&b, "\tPrerequisites: []string{},\n\tRelatedTopics: []string{},\n")
+	if c.DocsURL != "" {
+		field("DocsURL", c.DocsURL)
+	}
+	fmt.Fprintf(&b, "}\n\nfunc init() {\n\tRegister(%s)\n}\n", v)
+	return format.Source(b.Bytes())
+}
+
+// goString quotes multi-line text as a raw string, as the hand-written files
+// do for programs, falling back to an interpreted literal when it contains
+// a backquote.
+func goString(s string) string {
+	if strings.Contains(s, "\n") && !strings.Contains(s, "`") && !strings.Contains(s, "\r") {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
@@ -0,0 +1,89 @@
+// Command progress-merge combines two progress files exported from the
+// trainer (in any supported version) into one current-version file.
+//
+// Usage:
+//
+//	progress-merge [-o merged.json] [-strict] a.json b.json
+//
+// With -strict, concept IDs that do not exist in this build's curriculum are
+// an error; otherwise they are dropped with a warning.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"go-concept-trainer/concepts"
+	"go-concept-trainer/progress"
+)
+
+func main() {
+	out := flag.String("o", "", "write the merged file here instead of stdout")
+	strict := flag.Bool("strict", false, "fail on concept IDs unknown to this build instead of dropping them")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: progress-merge [-o out.json] [-strict] a.json b.json\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	known := make(map[string]bool)
+	for _, c := range concepts.GetAll() {
+		known[c.ID] = true
+	}
+	isKnown := func(id string) bool { return known[id] }
+
+	var files [2]*progress.File
+	for i, name := range flag.Args() {
+		f, err := load(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "progress-merge: %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		if err := f.Validate(nil); err != nil {
+			fmt.Fprintf(os.Stderr, "progress-merge: %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		if *strict {
+			if err := f.Validate(isKnown); err != nil {
+				fmt.Fprintf(os.Stderr, "progress-merge: %s: %v\n", name, err)
+				os.Exit(1)
+			}
+		}
+		for _, id := range f.Drop(isKnown) {
+			fmt.Fprintf(os.Stderr, "progress-merge: %s: dropping unknown concept %q\n", name, id)
+		}
+		files[i] = f
+	}
+
+	merged := progress.Merge(files[0], files[1])
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "progress-merge: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := merged.Write(w); err != nil {
+		fmt.Fprintf(os.Stderr, "progress-merge: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func load(name string) (*progress.File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return progress.Parse(f)
+}
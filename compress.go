@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Content encodings we can serve, in order of preference. Brotli is only
+// served from precompressed .br files since the standard library has no
+// encoder.
+var encodings = []struct {
+	name, ext string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header allows enc,
+// honouring q=0 exclusions and the * wildcard.
+func acceptsEncoding(header, enc string) bool {
+	wildcard := false
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case enc:
+			return q > 0
+		case "*":
+			wildcard = q > 0
+		}
+	}
+	return wildcard
+}
+
+// compressible reports whether a file is worth gzipping on our side.
+func compressible(name string) bool {
+	ctype := mime.TypeByExtension(path.Ext(name))
+	return strings.HasPrefix(ctype, "text/") ||
+		strings.Contains(ctype, "javascript") ||
+		strings.Contains(ctype, "json") ||
+		strings.Contains(ctype, "svg") ||
+		strings.HasPrefix(ctype, "application/wasm")
+}
+
+func gzipBytes(b []byte) []byte {
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	zw.Write(b)
+	zw.Close()
+	return buf.Bytes()
+}
+
+// strongETag returns a quoted strong entity tag for b.
+func strongETag(b []byte) string {
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// precompressed is an immutable response body held alongside its gzip form,
+// used for API payloads that are built once at startup.
+type precompressed struct {
+	raw, gz     []byte
+	etag        string
+	contentType string
+}
+
+func newPrecompressed(raw []byte, contentType string) *precompressed {
+	return &precompressed{raw: raw, gz: gzipBytes(raw), etag: strongETag(raw), contentType: contentType}
+}
+
+// serve writes the body with the given Cache-Control, answering conditional
+// requests with 304 and negotiating gzip.
+func (p *precompressed) serve(w http.ResponseWriter, r *http.Request, cacheControl string) {
+	h := w.Header()
+	h.Set("Content-Type", p.contentType)
+	h.Set("Cache-Control", cacheControl)
+	h.Add("Vary", "Accept-Encoding")
+
+	body, etag := p.raw, p.etag
+	if acceptsEncoding(r.Header.Get("Accept-Encoding"), "gzip") {
+		body = p.gz
+		etag = strings.TrimSuffix(p.etag, `"`) + `-gzip"`
+		h.Set("Content-Encoding", "gzip")
+	}
+	h.Set("ETag", etag)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+}
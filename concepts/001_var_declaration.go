@@ -7,7 +7,7 @@ var Concept001 = Concept{
 	Category:    "Core Syntax",
 	Name:        "1. Variable Declaration (var)",
 	Description: "Declare variables using var keyword with explicit type",
-	Instruction: "Declare an integer variable named x with the value 42, then print it",
+	Instruction: "Declare an integer variable named x with the value {{value}}, then print it",
 	Boilerplate: `package main
 
 import "fmt"
@@ -20,7 +20,7 @@ func main() {
 import "fmt"
 
 func main() {
-	var x int = 42
+	var x int = {{value}}
 	fmt.Println(x)
 }`,
 	ExpectedOutput: "42",
@@ -31,6 +31,9 @@ func main() {
 	Prerequisites:  nil,
 	RelatedTopics:  []string{"short-declaration", "constants", "type-conversion"},
 	DocsURL:        "https://go.dev/tour/basics/8",
+	Params: []Param{
+		{Name: "value", Min: 10, Max: 999, Default: "42"},
+	},
 }
 
 func init() {
@@ -7,7 +7,7 @@ var Concept002 = Concept{
 	Category:    "Core Syntax",
 	Name:        "2. Short Variable Declaration",
 	Description: "Use := for short variable declaration (type inferred)",
-	Instruction: "Declare a variable y with the value hello using short declaration syntax and print it",
+	Instruction: "Declare a variable y with the value {{word}} using short declaration syntax and print it",
 	Boilerplate: `package main
 
 import "fmt"
@@ -20,7 +20,7 @@ func main() {
 import "fmt"
 
 func main() {
-	y := "hello"
+	y := "{{word}}"
 	fmt.Println(y)
 }`,
 	ExpectedOutput: "hello",
@@ -31,6 +31,9 @@ func main() {
 	Prerequisites:  []string{"var-declaration"},
 	RelatedTopics:  []string{"var-declaration", "type-conversion", "multiple-assignment"},
 	DocsURL:        "https://go.dev/tour/basics/10",
+	Params: []Param{
+		{Name: "word", Values: []string{"hello", "gopher", "channel", "defer", "slice"}},
+	},
 }
 
 func init() {
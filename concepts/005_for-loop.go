@@ -7,7 +7,7 @@ var Concept005 = Concept{
 	Category:    "Core Syntax",
 	Name:        "5. For Loop",
 	Description: "Basic for loop with init, condition, post",
-	Instruction: "Print numbers 1 to 3, one per line, using a for loop",
+	Instruction: "Print numbers 1 to {{n}}, one per line, using a for loop",
 	Boilerplate: `package main
 
 import "fmt"
@@ -20,7 +20,7 @@ func main() {
 import "fmt"
 
 func main() {
-	for i := 1; i <= 3; i++ {
+	for i := 1; i <= {{n}}; i++ {
 		fmt.Println(i)
 	}
 }`,
@@ -32,6 +32,9 @@ func main() {
 	Prerequisites:  []string{"var-declaration", "short-declaration"},
 	RelatedTopics:  []string{"for-while", "range-slice", "if-else"},
 	DocsURL:        "https://go.dev/tour/flowcontrol/1",
+	Params: []Param{
+		{Name: "n", Min: 3, Max: 7, Default: "3"},
+	},
 }
 
 func init() {
@@ -7,7 +7,7 @@ var Concept014 = Concept{
 	Category:    "Data Structures",
 	Name:        "14. Slice Basics",
 	Description: "Create a slice with literal",
-	Instruction: "Create a slice containing the integers 10 and 20, then print the first element",
+	Instruction: "Create a slice containing the integers {{first}} and {{second}}, then print the first element",
 	Boilerplate: `package main
 
 import "fmt"
@@ -20,7 +20,7 @@ func main() {
 import "fmt"
 
 func main() {
-	s := []int{10, 20}
+	s := []int{{{first}}, {{second}}}
 	fmt.Println(s[0])
 }`,
 	ExpectedOutput: "10",
@@ -31,6 +31,10 @@ func main() {
 	Prerequisites:  []string{"array-declaration"},
 	RelatedTopics:  []string{"slice-make", "slice-append", "slice-slicing", "array-declaration"},
 	DocsURL:        "https://go.dev/tour/moretypes/7",
+	Params: []Param{
+		{Name: "first", Min: 1, Max: 99, Default: "10"},
+		{Name: "second", Min: 1, Max: 99, Default: "20"},
+	},
 }
 
 func init() {
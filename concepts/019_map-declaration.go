@@ -31,6 +31,20 @@ func main() {
 	Prerequisites:  []string{"var-declaration"},
 	RelatedTopics:  []string{"map-make", "map-existence", "map-delete"},
 	DocsURL:        "https://go.dev/tour/moretypes/19",
+	Quiz: []Question{
+		{
+			Prompt:    "What is the zero value of a map[string]int?",
+			Choices:   []string{"An empty map ready for use", "nil", "map[string]int{\"\": 0}"},
+			Answer:    1,
+			Rationale: "Maps are reference types; an uninitialised map variable is nil. Reading from it returns zero values, but writing to it panics until it is made with make or a literal.",
+		},
+		{
+			Prompt:    "What does m[\"missing\"] return for m := map[string]int{\"a\": 1}?",
+			Choices:   []string{"0", "It panics", "nil"},
+			Answer:    0,
+			Rationale: "Indexing a map with a key that is not present returns the value type's zero value. Use v, ok := m[k] to tell a missing key from a stored zero.",
+		},
+	},
 }
 
 func init() {
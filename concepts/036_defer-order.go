@@ -32,6 +32,14 @@ func main() {
 	Prerequisites:  []string{"defer"},
 	RelatedTopics:  []string{"defer", "panic-recover"},
 	DocsURL:        "https://go.dev/blog/defer-panic-and-recover",
+	Quiz: []Question{
+		{
+			Prompt:    "When are the arguments of a deferred call evaluated?",
+			Choices:   []string{"When the surrounding function returns", "When the defer statement executes", "When the deferred call runs"},
+			Answer:    1,
+			Rationale: "The function value and its arguments are evaluated when the defer statement executes; only the call itself waits until the surrounding function returns.",
+		},
+	},
 }
 
 func init() {
@@ -33,6 +33,20 @@ func main() {
 	Prerequisites:  []string{"channel-create"},
 	RelatedTopics:  []string{"range-channel", "channel-send-receive"},
 	DocsURL:        "https://go.dev/tour/concurrency/4",
+	Quiz: []Question{
+		{
+			Prompt:    "What happens when you receive from a closed, empty channel?",
+			Choices:   []string{"It blocks forever", "It panics", "It returns the zero value immediately"},
+			Answer:    2,
+			Rationale: "Receives on a closed channel never block: once buffered values are drained they return the zero value, and the comma-ok form reports ok == false.",
+		},
+		{
+			Prompt:    "What happens when you send on a closed channel?",
+			Choices:   []string{"It panics", "The value is dropped", "It blocks until a receiver arrives"},
+			Answer:    0,
+			Rationale: "Sending on a closed channel panics, which is why only the sender should close a channel, and only once it is done sending.",
+		},
+	},
 }
 
 func init() {
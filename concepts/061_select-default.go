@@ -36,6 +36,20 @@ func main() {
 	Prerequisites:  []string{"select"},
 	RelatedTopics:  []string{"select", "select-timeout"},
 	DocsURL:        "https://go.dev/tour/concurrency/6",
+	Quiz: []Question{
+		{
+			Prompt:    "When does a select with a default case block?",
+			Choices:   []string{"When no channel is ready", "Never", "When every channel is nil"},
+			Answer:    1,
+			Rationale: "If no case can proceed, select runs the default case straight away, so a select with default never blocks. Without default it waits until some case is ready.",
+		},
+		{
+			Prompt:    "Several cases of a select are ready at once. Which one runs?",
+			Choices:   []string{"The first one in source order", "The one whose channel was ready first", "One chosen at random"},
+			Answer:    2,
+			Rationale: "Select picks uniformly at random among the ready cases, so no channel can starve the others. Do not rely on case order for priority.",
+		},
+	},
 }
 
 func init() {
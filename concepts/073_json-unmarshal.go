@@ -28,7 +28,9 @@ import (
 func main() {
 	type T struct { X int }
 	var t T
-	json.Unmarshal([]byte(`,
+	json.Unmarshal([]byte("{\"X\": 2}"), &t)
+	fmt.Println(t.X)
+}`,
 	ExpectedOutput: "2",
 	Difficulty:     "beginner",
 	Explanation:    "json.Unmarshal parses JSON-encoded data and stores it in a Go value. Pass a pointer to the destination variable. JSON field names are matched to struct fields (case-insensitive). Returns an error if parsing fails.",
@@ -30,7 +30,11 @@ import (
 func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
 	defer cancel()
-	fmt.Println("done")
+	select {
+	case <-ctx.Done():
+	default:
+		fmt.Println("done")
+	}
 }`,
 	ExpectedOutput: "done",
 	Difficulty:     "advanced",
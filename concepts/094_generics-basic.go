@@ -19,8 +19,9 @@ func main() {
 
 import "fmt"
 
+func First[T any](s []T) T { return s[0] }
+
 func main() {
-	func First[T any](s []T) T { return s[0] }
 	fmt.Println(First([]int{5, 10}))
 }`,
 	ExpectedOutput: "5",
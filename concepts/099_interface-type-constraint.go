@@ -19,9 +19,11 @@ func main() {
 
 import "fmt"
 
+type Number interface{ ~int | ~float64 }
+
+func Double[T Number](n T) T { return n * 2 }
+
 func main() {
-	type Number interface { ~int | ~float64 }
-	func Double[T Number](n T) T { return n * 2 }
 	fmt.Println(Double(3))
 }`,
 	ExpectedOutput: "6",
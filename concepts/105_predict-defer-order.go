@@ -0,0 +1,41 @@
+package concepts
+
+// 105. Predict: Deferred Call Order
+var Concept105 = Concept{
+	Number:      105,
+	ID:          "predict-defer-order",
+	Category:    "Functions & Closures",
+	Kind:        KindPredict,
+	Name:        "105. Predict: Deferred Call Order",
+	Description: "Read a program with stacked defers and predict its output",
+	Instruction: "Read the program and type exactly what it prints.",
+	Answer: `package main
+
+import "fmt"
+
+func trace(name string) string {
+	fmt.Println("enter", name)
+	return name
+}
+
+func leave(name string) {
+	fmt.Println("leave", name)
+}
+
+func main() {
+	defer leave(trace("a"))
+	defer leave(trace("b"))
+	fmt.Println("body")
+}`,
+	Difficulty:    "intermediate",
+	Explanation:   "Deferred calls run when the surrounding function returns, in last-in-first-out order. Their arguments are evaluated when the defer statement executes, so trace runs immediately and prints \"enter\" before the body. Only the leave calls wait, and they unwind from the last one registered.",
+	Example:       "defer fmt.Println(\"first\")\ndefer fmt.Println(\"second\")\n// prints second, then first, when the function returns",
+	UseCase:       "Recognise defer stacking and eager argument evaluation when reading cleanup and tracing code.",
+	Prerequisites: []string{"defer", "defer-order"},
+	RelatedTopics: []string{"defer-order", "closure"},
+	DocsURL:       "https://go.dev/ref/spec#Defer_statements",
+}
+
+func init() {
+	Register(Concept105)
+}
@@ -0,0 +1,40 @@
+package concepts
+
+// 106. Predict: Shadowed Variables
+var Concept106 = Concept{
+	Number:      106,
+	ID:          "predict-shadowing",
+	Category:    "Core Syntax",
+	Kind:        KindPredict,
+	Name:        "106. Predict: Shadowed Variables",
+	Description: "Read a program that shadows a variable in nested scopes and predict its output",
+	Instruction: "Read the program and type exactly what it prints.",
+	Answer: `package main
+
+import "fmt"
+
+func main() {
+	x := 1
+	if x := x * 10; x > 5 {
+		fmt.Println(x)
+	}
+	x, y := 2, 3
+	fmt.Println(x, y)
+	func() {
+		x := x + y
+		fmt.Println(x)
+	}()
+	fmt.Println(x)
+}`,
+	Difficulty:    "intermediate",
+	Explanation:   "Each := in a new scope declares a new variable that hides the outer one until the scope ends. The if statement's x is 10 only inside the if. x, y := 2, 3 reuses the outer x because y is new, so it assigns rather than shadows. Inside the closure, x := x + y reads the outer x before declaring the inner one.",
+	Example:       "x := 1\n{\n    x := 2 // new variable, hides the outer x\n    _ = x\n}\nfmt.Println(x) // 1",
+	UseCase:       "Spot shadowing bugs when reading code, especially err := inside if blocks that leaves the outer err unset.",
+	Prerequisites: []string{"shadowing"},
+	RelatedTopics: []string{"shadowing", "closure"},
+	DocsURL:       "https://go.dev/ref/spec#Declarations_and_scope",
+}
+
+func init() {
+	Register(Concept106)
+}
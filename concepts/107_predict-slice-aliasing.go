@@ -0,0 +1,37 @@
+package concepts
+
+// 107. Predict: Slice Aliasing
+var Concept107 = Concept{
+	Number:      107,
+	ID:          "predict-slice-aliasing",
+	Category:    "Data Structures",
+	Kind:        KindPredict,
+	Name:        "107. Predict: Slice Aliasing",
+	Description: "Read a program where two slices share a backing array and predict its output",
+	Instruction: "Read the program and type exactly what it prints.",
+	Answer: `package main
+
+import "fmt"
+
+func main() {
+	a := []int{1, 2, 3, 4}
+	b := a[1:3]
+	b[0] = 20
+	b = append(b, 30)
+	fmt.Println(a)
+	b = append(b, 40)
+	b[0] = 99
+	fmt.Println(a, b)
+}`,
+	Difficulty:    "advanced",
+	Explanation:   "b := a[1:3] shares a's backing array with length 2 and capacity 3, so writing b[0] changes a[1]. The first append fits in that capacity and overwrites a[3]. The second append exceeds it, so Go copies b to a new array; after that, writes to b no longer reach a.",
+	Example:       "a := []int{1, 2, 3}\nb := a[:2]\nb = append(b, 9) // fits in capacity: a is now [1 2 9]",
+	UseCase:       "Predict when a sub-slice still aliases its parent, which matters whenever a function appends to a slice it was given.",
+	Prerequisites: []string{"slice-slicing", "slice-append", "slice-capacity"},
+	RelatedTopics: []string{"slice-capacity", "slice-copy"},
+	DocsURL:       "https://go.dev/blog/slices-intro",
+}
+
+func init() {
+	Register(Concept107)
+}
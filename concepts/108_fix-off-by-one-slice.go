@@ -0,0 +1,62 @@
+package concepts
+
+// 108. Fix: Off-by-One Slice Bounds
+var Concept108 = Concept{
+	Number:      108,
+	ID:          "fix-off-by-one-slice",
+	Category:    "Data Structures",
+	Kind:        KindFix,
+	Name:        "108. Fix: Off-by-One Slice Bounds",
+	Description: "Fix a window function whose slice bounds are off by one",
+	Instruction: "windows should print every run of 3 consecutive readings, but it panics. Find the bug and fix it by changing as few lines as possible.",
+	Boilerplate: `package main
+
+import "fmt"
+
+// windows returns every run of size consecutive values in s.
+func windows(s []int, size int) [][]int {
+	var out [][]int
+	for i := 0; i <= len(s)-size+1; i++ {
+		out = append(out, s[i:i+size])
+	}
+	return out
+}
+
+func main() {
+	readings := []int{3, 1, 4, 1, 5}
+	for _, w := range windows(readings, 3) {
+		fmt.Println(w)
+	}
+}`,
+	Answer: `package main
+
+import "fmt"
+
+// windows returns every run of size consecutive values in s.
+func windows(s []int, size int) [][]int {
+	var out [][]int
+	for i := 0; i <= len(s)-size; i++ {
+		out = append(out, s[i:i+size])
+	}
+	return out
+}
+
+func main() {
+	readings := []int{3, 1, 4, 1, 5}
+	for _, w := range windows(readings, 3) {
+		fmt.Println(w)
+	}
+}`,
+	ExpectedOutput: "[3 1 4]\n[1 4 1]\n[4 1 5]",
+	Difficulty:     "beginner",
+	Explanation:    "A slice of length n has len(s)-size+1 windows of a given size, starting at indexes 0 through len(s)-size. With <= len(s)-size+1 the loop runs once too often and s[i:i+size] reaches past the end of the slice, which panics with \"slice bounds out of range\".",
+	Example:        "s := []int{1, 2, 3}\nfor i := 0; i < len(s); i++ { // not <=\n    fmt.Println(s[i])\n}",
+	UseCase:        "Spot off-by-one loop bounds when slicing buffers, paginating results or computing moving averages.",
+	Prerequisites:  []string{"slice-slicing", "for-loop"},
+	RelatedTopics:  []string{"slice-capacity"},
+	DocsURL:        "https://go.dev/ref/spec#Slice_expressions",
+}
+
+func init() {
+	Register(Concept108)
+}
@@ -0,0 +1,68 @@
+package concepts
+
+// 109. Fix: Nil Map Write
+var Concept109 = Concept{
+	Number:      109,
+	ID:          "fix-nil-map-write",
+	Category:    "Data Structures",
+	Kind:        KindFix,
+	Name:        "109. Fix: Nil Map Write",
+	Description: "Fix a word counter that writes to a nil map",
+	Instruction: "The program should count how often each word appears, but it panics. Find the bug and fix it by changing as few lines as possible.",
+	Boilerplate: `package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type counter struct {
+	counts map[string]int
+}
+
+func (c *counter) add(text string) {
+	for _, w := range strings.Fields(text) {
+		c.counts[w]++
+	}
+}
+
+func main() {
+	var c counter
+	c.add("the cat saw the dog")
+	fmt.Println(c.counts["the"], c.counts["dog"], len(c.counts))
+}`,
+	Answer: `package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type counter struct {
+	counts map[string]int
+}
+
+func (c *counter) add(text string) {
+	for _, w := range strings.Fields(text) {
+		c.counts[w]++
+	}
+}
+
+func main() {
+	c := counter{counts: make(map[string]int)}
+	c.add("the cat saw the dog")
+	fmt.Println(c.counts["the"], c.counts["dog"], len(c.counts))
+}`,
+	ExpectedOutput: "2 1 4",
+	Difficulty:     "beginner",
+	Explanation:    "The zero value of a map is nil. Reading from a nil map returns zero values, but writing to one panics with \"assignment to entry in nil map\". A struct's zero value leaves its map fields nil, so they must be made before use, here when the counter is created.",
+	Example:        "var m map[string]int\n_ = m[\"a\"]  // fine: 0\nm = make(map[string]int)\nm[\"a\"] = 1 // fine once made",
+	UseCase:        "Spot nil map writes in structs built from their zero value, a common panic in caches and counters.",
+	Prerequisites:  []string{"map-make", "struct-literal"},
+	RelatedTopics:  []string{"zero-values"},
+	DocsURL:        "https://go.dev/ref/spec#Map_types",
+}
+
+func init() {
+	Register(Concept109)
+}
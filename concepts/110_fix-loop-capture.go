@@ -0,0 +1,57 @@
+package concepts
+
+// 110. Fix: Captured Loop State
+var Concept110 = Concept{
+	Number:      110,
+	ID:          "fix-loop-capture",
+	Category:    "Functions & Closures",
+	Kind:        KindFix,
+	Name:        "110. Fix: Captured Loop State",
+	Description: "Fix closures that all capture the same variable",
+	Instruction: "Each greeter should greet a different name, but they all greet the last one. Find the bug and fix it by changing as few lines as possible.",
+	Boilerplate: `package main
+
+import "fmt"
+
+func main() {
+	var greeters []func()
+	var name string
+	for _, n := range []string{"Ada", "Grace", "Ken"} {
+		name = n
+		greeters = append(greeters, func() {
+			fmt.Println("hello,", name)
+		})
+	}
+	for _, greet := range greeters {
+		greet()
+	}
+}`,
+	Answer: `package main
+
+import "fmt"
+
+func main() {
+	var greeters []func()
+	for _, n := range []string{"Ada", "Grace", "Ken"} {
+		name := n
+		greeters = append(greeters, func() {
+			fmt.Println("hello,", name)
+		})
+	}
+	for _, greet := range greeters {
+		greet()
+	}
+}`,
+	ExpectedOutput: "hello, Ada\nhello, Grace\nhello, Ken",
+	Difficulty:     "intermediate",
+	Explanation:    "A closure captures variables, not their values. Since Go 1.22 each loop iteration has its own copy of the loop variables, but name is declared outside the loop, so all three closures share it and see its last value when they finally run. Declaring the variable inside the loop body gives each closure its own.",
+	Example:        "x := 1\nf := func() { fmt.Println(x) }\nx = 2\nf() // prints 2",
+	UseCase:        "Spot shared captured state in callbacks, deferred work and goroutines started in a loop.",
+	Prerequisites:  []string{"closure", "range-slice"},
+	RelatedTopics:  []string{"shadowing", "goroutine"},
+	DocsURL:        "https://go.dev/blog/loopvar-preview",
+}
+
+func init() {
+	Register(Concept110)
+}
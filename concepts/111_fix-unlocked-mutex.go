@@ -0,0 +1,96 @@
+package concepts
+
+// 111. Fix: Unlocked Mutex
+var Concept111 = Concept{
+	Number:      111,
+	ID:          "fix-unlocked-mutex",
+	Category:    "Concurrency",
+	Kind:        KindFix,
+	Name:        "111. Fix: Unlocked Mutex",
+	Description: "Fix a counter whose mutex is never locked",
+	Instruction: "10 goroutines each add 10 to the counter, so it should print 100, but it prints much less. Find the bug and fix it by changing as few lines as possible.",
+	Boilerplate: `package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Counter struct {
+	mu sync.Mutex
+	n  int
+}
+
+// Inc reads the count, does some slow bookkeeping and writes it back.
+func (c *Counter) Inc() {
+	n := c.n
+	time.Sleep(time.Millisecond)
+	c.n = n + 1
+}
+
+func main() {
+	var c Counter
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				c.Inc()
+			}
+		}()
+	}
+	wg.Wait()
+	fmt.Println(c.n)
+}`,
+	Answer: `package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Counter struct {
+	mu sync.Mutex
+	n  int
+}
+
+// Inc reads the count, does some slow bookkeeping and writes it back.
+func (c *Counter) Inc() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := c.n
+	time.Sleep(time.Millisecond)
+	c.n = n + 1
+}
+
+func main() {
+	var c Counter
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				c.Inc()
+			}
+		}()
+	}
+	wg.Wait()
+	fmt.Println(c.n)
+}`,
+	ExpectedOutput: "100",
+	Difficulty:     "intermediate",
+	Explanation:    "A sync.Mutex only protects data if every access locks it. Inc reads the count and writes it back later; when goroutines interleave those steps, one overwrites another's increment and it is lost. Having a mutex in the struct does nothing by itself: Inc must Lock it and Unlock it, usually with defer so every return path releases it.",
+	Example:        "mu.Lock()\ndefer mu.Unlock()\nshared++",
+	UseCase:        "Spot missing locks in shared counters, caches and connection pools; go run -race reports them too.",
+	Prerequisites:  []string{"mutex", "waitgroup"},
+	RelatedTopics:  []string{"atomic-operations", "goroutine"},
+	DocsURL:        "https://pkg.go.dev/sync#Mutex",
+}
+
+func init() {
+	Register(Concept111)
+}
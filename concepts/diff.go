@@ -0,0 +1,96 @@
+package concepts
+
+import "strings"
+
+// LinesChanged counts the lines that differ between two versions of a
+// program. A replaced line counts once, as does each inserted or deleted
+// line. Indentation and blank lines are ignored, so reformatting is free.
+func LinesChanged(from, to string) int {
+	n := 0
+	for _, h := range diffLines(from, to) {
+		n += max(h.deleted, h.inserted)
+	}
+	return n
+}
+
+// FirstChangedLine returns the 1-based line of from where to first differs,
+// or 0 if they match.
+func FirstChangedLine(from, to string) int {
+	hunks := diffLines(from, to)
+	if len(hunks) == 0 {
+		return 0
+	}
+	return hunks[0].line
+}
+
+// hunk is a run of consecutive differences: deleted lines of from replaced
+// by inserted lines of to, starting at line of from.
+type hunk struct {
+	line              int
+	deleted, inserted int
+}
+
+type sourceLine struct {
+	number int
+	text   string
+}
+
+func significantLines(src string) []sourceLine {
+	var lines []sourceLine
+	for i, l := range strings.Split(src, "\n") {
+		if t := strings.TrimSpace(l); t != "" {
+			lines = append(lines, sourceLine{i + 1, t})
+		}
+	}
+	return lines
+}
+
+// diffLines compares the significant lines of two programs through their
+// longest common subsequence. Programs here are short, so the quadratic
+// table is fine.
+func diffLines(from, to string) []hunk {
+	a, b := significantLines(from), significantLines(to)
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].text == b[j].text {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var hunks []hunk
+	var cur *hunk
+	at := func(i int) *hunk {
+		if cur == nil {
+			line := len(strings.Split(from, "\n"))
+			if i < len(a) {
+				line = a[i].number
+			}
+			hunks = append(hunks, hunk{line: line})
+			cur = &hunks[len(hunks)-1]
+		}
+		return cur
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i].text == b[j].text:
+			cur = nil
+			i, j = i+1, j+1
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			at(i).inserted++
+			j++
+		default:
+			at(i).deleted++
+			i++
+		}
+	}
+	return hunks
+}
@@ -0,0 +1,179 @@
+package concepts
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Hint kinds, from least to most help.
+const (
+	HintNudge    = "nudge"    // what to think about
+	HintAPI      = "api"      // which functions and docs are relevant
+	HintSkeleton = "skeleton" // the answer's shape with statements elided
+	HintLocation = "location" // the line a fix-the-bug concept's bug is on
+	HintAnswer   = "answer"   // the full reference answer
+)
+
+// Hint is one rung of a concept's hint ladder. Level counts from 1.
+type Hint struct {
+	Level int    `json:"level"`
+	Kind  string `json:"kind"`
+	Text  string `json:"text"`
+}
+
+// HintLadder returns the concept's hints in reveal order. Authored Hints
+// come first when present; otherwise a nudge, the relevant API and a
+// skeleton are derived from the concept, or for KindFix concepts the
+// location of the bug. The full answer is always last.
+// For KindPredict concepts the answer is the program's output, which only
+// running it can tell, so its Text is left for the server to fill in.
+func (c Concept) HintLadder() []Hint {
+	hints := slices.Clone(c.Hints)
+	if len(hints) == 0 {
+		hints = c.derivedHints()
+	}
+	answer := c.Answer
+	if c.Kind == KindPredict {
+		answer = ""
+	}
+	hints = append(hints, Hint{Kind: HintAnswer, Text: answer})
+	for i := range hints {
+		hints[i].Level = i + 1
+	}
+	return hints
+}
+
+func (c Concept) derivedHints() []Hint {
+	var hints []Hint
+	nudge := c.Description
+	if len(c.Prerequisites) > 0 {
+		nudge += ". It builds on: " + strings.Join(c.Prerequisites, ", ") + "."
+	}
+	hints = append(hints, Hint{Kind: HintNudge, Text: nudge})
+	if c.Kind == KindPredict {
+		// The program is already on screen; API and skeleton hints
+		// would only repeat it.
+		return hints
+	}
+	if c.Kind == KindFix {
+		// A skeleton of the fix is the starting program again; point
+		// at the line that needs changing instead.
+		if n := FirstChangedLine(c.Boilerplate, c.Answer); n > 0 {
+			line := strings.TrimSpace(strings.Split(c.Boilerplate, "\n")[n-1])
+			hints = append(hints, Hint{Kind: HintLocation, Text: "Look at line " + strconv.Itoa(n) + ": " + line})
+		}
+		return hints
+	}
+
+	var api []string
+	if calls := qualifiedNames(c.Answer); len(calls) > 0 {
+		api = append(api, "Relevant API: "+strings.Join(calls, ", "))
+	}
+	if c.DocsURL != "" {
+		api = append(api, "Docs: "+c.DocsURL)
+	}
+	if len(api) > 0 {
+		hints = append(hints, Hint{Kind: HintAPI, Text: strings.Join(api, "\n")})
+	}
+
+	if s := skeleton(c.Answer); s != "" && s != c.Answer {
+		hints = append(hints, Hint{Kind: HintSkeleton, Text: s})
+	}
+	return hints
+}
+
+// qualifiedNames lists the package-qualified identifiers a program uses,
+// such as json.Unmarshal, in order of first use. fmt.Print* is left out
+// unless it is all there is, since nearly every answer prints.
+func qualifiedNames(src string) []string {
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return nil
+	}
+	imported := make(map[string]bool)
+	for _, imp := range f.Imports {
+		path := strings.Trim(imp.Path.Value, `"`)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		imported[name] = true
+	}
+
+	var names, printing []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); ok && imported[x.Name] {
+			name := x.Name + "." + sel.Sel.Name
+			switch {
+			case x.Name == "fmt" && strings.HasPrefix(sel.Sel.Name, "Print"):
+				if !slices.Contains(printing, name) {
+					printing = append(printing, name)
+				}
+			case !slices.Contains(names, name):
+				names = append(names, name)
+			}
+		}
+		return true
+	})
+	if len(names) == 0 {
+		return printing
+	}
+	return names
+}
+
+// skeleton keeps a program's declarations and control structure and the
+// shape of each statement inside functions, eliding the details: "y :=
+// ...", "fmt.Println(...)". It works on gofmt-style text: a function runs
+// from a "func" line at column zero to the next "}" at column zero.
+func skeleton(src string) string {
+	var out []string
+	inFunc := false
+	for _, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "func ") && strings.HasSuffix(trimmed, "{"):
+			inFunc = true
+		case line == "}":
+			inFunc = false
+		case inFunc && trimmed != "" && !strings.HasSuffix(trimmed, "{") && !strings.HasPrefix(trimmed, "}") &&
+			!strings.HasPrefix(trimmed, "case ") && trimmed != "default:":
+			indent := line[:len(line)-len(strings.TrimLeft(line, "\t "))]
+			line = indent + elide(trimmed)
+			if len(out) > 0 && out[len(out)-1] == line {
+				continue
+			}
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// elide reduces a simple statement to its shape.
+func elide(stmt string) string {
+	switch {
+	case strings.HasPrefix(stmt, "//"), strings.HasPrefix(stmt, "type "), stmt == "return",
+		strings.HasPrefix(stmt, "var ") && !strings.Contains(stmt, "="):
+		return stmt
+	case strings.HasPrefix(stmt, "return "):
+		return "return ..."
+	case strings.HasPrefix(stmt, "go "), strings.HasPrefix(stmt, "defer "):
+		return stmt[:strings.IndexByte(stmt, ' ')] + " ..."
+	}
+	for _, op := range []string{" := ", " = ", " += ", " -= "} {
+		if i := strings.Index(stmt, op); i > 0 && !strings.ContainsAny(stmt[:i], "(\"") {
+			return stmt[:i] + op + "..."
+		}
+	}
+	if i := strings.IndexByte(stmt, '('); i > 0 && !strings.ContainsAny(stmt[:i], " \"") {
+		return stmt[:i] + "(...)"
+	}
+	return "// ..."
+}
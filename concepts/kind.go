@@ -0,0 +1,21 @@
+package concepts
+
+// Exercise kinds. Register sets KindWrite on concepts that leave Kind empty.
+const (
+	// KindWrite asks for a program that prints ExpectedOutput; Answer is
+	// a reference solution.
+	KindWrite = "write"
+	// KindPredict shows the program in Answer and asks for exactly what it
+	// prints. ExpectedOutput is not authored: the server runs Answer to
+	// grade, so the expected output cannot go stale.
+	KindPredict = "predict"
+	// KindFix starts from a Boilerplate with a deliberate bug. A fix must
+	// print ExpectedOutput and change at most MaxLinesChanged lines, so
+	// rewriting the program from scratch does not pass. Answer is the
+	// reference fix.
+	KindFix = "fix"
+)
+
+// fixSlack is how many more lines than the reference fix a learner's fix
+// may change when the concept does not set MaxLinesChanged.
+const fixSlack = 2
@@ -26,5 +26,7 @@ var _ = []Concept{
 	Concept086, Concept087, Concept088, Concept089, Concept090,
 	Concept091, Concept092, Concept093, Concept094, Concept095,
 	Concept096, Concept097, Concept098, Concept099, Concept100,
-	Concept101, Concept102, Concept103, Concept104,
+	Concept101, Concept102, Concept103, Concept104, Concept105,
+	Concept106, Concept107, Concept108, Concept109, Concept110,
+	Concept111,
 }
@@ -0,0 +1,75 @@
+package concepts
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// Param is a template variable of a parameterised concept. Instruction,
+// Boilerplate and Answer refer to it as {{Name}}, and each attempt
+// replaces it with a value picked from the attempt's seed.
+type Param struct {
+	Name string
+	// Values are the candidates, substituted verbatim: a number, the
+	// inside of a string literal, or slice contents such as "3, 1, 4".
+	Values []string
+	// Without Values, the value is a random integer in [Min, Max], or
+	// with Count set, that many comma-separated ones.
+	Min, Max, Count int
+	// Default is the value of the default variant, which the authored
+	// ExpectedOutput is for. It defaults to Values[0], or Min.
+	Default string
+}
+
+// pick returns the parameter's value for r, or its default if r is nil.
+func (p Param) pick(r *rand.Rand) string {
+	switch {
+	case r == nil && p.Default != "":
+		return p.Default
+	case r == nil && len(p.Values) > 0:
+		return p.Values[0]
+	case len(p.Values) > 0:
+		return p.Values[r.IntN(len(p.Values))]
+	}
+	ints := make([]string, max(p.Count, 1))
+	for i := range ints {
+		v := p.Min
+		if r != nil {
+			v += r.IntN(p.Max - p.Min + 1)
+		}
+		ints[i] = strconv.Itoa(v)
+	}
+	return strings.Join(ints, ", ")
+}
+
+// Variant instantiates a parameterised concept for one attempt. Seed 0 is
+// the default variant, which is what Register stores, so every other
+// consumer sees an ordinary concept. Other seeds pick fresh values; the
+// variant's ExpectedOutput is then empty, since only running its Answer
+// can tell it. Concepts without Params are returned unchanged.
+func (c Concept) Variant(seed uint64) Concept {
+	if c.template == nil {
+		return c
+	}
+	t := c.template
+	var r *rand.Rand
+	if seed != 0 {
+		r = rand.New(rand.NewPCG(seed, seed))
+	}
+	pairs := make([]string, 0, 2*len(t.Params))
+	for _, p := range t.Params {
+		pairs = append(pairs, "{{"+p.Name+"}}", p.pick(r))
+	}
+	fill := strings.NewReplacer(pairs...).Replace
+
+	v := c
+	v.Instruction = fill(t.Instruction)
+	v.Boilerplate = fill(t.Boilerplate)
+	v.Answer = fill(t.Answer)
+	v.ExpectedOutput = t.ExpectedOutput
+	if seed != 0 {
+		v.ExpectedOutput = ""
+	}
+	return v
+}
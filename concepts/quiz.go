@@ -0,0 +1,12 @@
+package concepts
+
+// Question is a multiple-choice quiz question about a concept, for
+// knowledge that is better tested without code: "what is the zero value
+// of a map?". The server sends only the prompt and choices; Answer and
+// Rationale are revealed once the learner has answered.
+type Question struct {
+	Prompt    string   `json:"prompt"`
+	Choices   []string `json:"choices"`
+	Answer    int      `json:"answer"`    // index into Choices
+	Rationale string   `json:"rationale"` // why the answer is right
+}
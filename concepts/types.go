@@ -9,6 +9,7 @@ type Concept struct {
 	Number         int        `json:"number"`         // LeetCode-style number
 	ID             string     `json:"id"`
 	Category       string     `json:"category"`
+	Kind           string     `json:"kind"`
 	Name           string     `json:"name"`
 	Description    string     `json:"description"`
 	Instruction    string     `json:"instruction"`
@@ -23,12 +24,40 @@ type Concept struct {
 	Prerequisites  []string   `json:"prerequisites"`
 	RelatedTopics  []string   `json:"relatedTopics"`
 	DocsURL        string     `json:"docsUrl"`
+	Hints          []Hint     `json:"hints,omitempty"` // optional authored hints; see HintLadder
+	Quiz           []Question `json:"quiz,omitempty"`  // optional questions answered without code
+	Params         []Param    `json:"-"`               // template variables; see Variant
+	// MaxLinesChanged bounds a KindFix solution's diff from Boilerplate.
+	// Register defaults it to the reference fix plus a little slack.
+	MaxLinesChanged int    `json:"maxLinesChanged,omitempty"`
+	Version         string `json:"version"` // set by Register from ContentVersion
+
+	template *Concept // as authored, for parameterised concepts
 }
 
 // Initialize allConcepts at package level so it's ready before any init() functions run
-var allConcepts = make([]Concept, 0, 104)
+var allConcepts = make([]Concept, 0, 111)
 
 func Register(c Concept) {
+	if c.Kind == "" {
+		c.Kind = KindWrite
+	}
+	if c.Kind == KindPredict && c.Boilerplate == "" {
+		c.Boilerplate = c.Answer
+	}
+	if c.Kind == KindFix && c.MaxLinesChanged == 0 {
+		c.MaxLinesChanged = LinesChanged(c.Boilerplate, c.Answer) + fixSlack
+	}
+	if len(c.Params) > 0 {
+		// ExpectedOutput is authored for the default values.
+		t := c
+		c.template = &t
+		c = c.Variant(0)
+	}
+	// Versioning the default variant rather than the template keeps a
+	// concept's version when it gains parameters whose defaults reproduce
+	// its old text.
+	c.Version = c.ContentVersion()
 	allConcepts = append(allConcepts, c)
 }
 
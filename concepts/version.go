@@ -0,0 +1,36 @@
+package concepts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// ContentVersion returns a short hash of the fields that decide whether a
+// solution is correct: the instruction, expected output and test cases, for
+// predict-the-output concepts the program itself, and for fix-the-bug
+// concepts the starting program and allowed diff.
+// Edits to explanations, examples or boilerplate do not change it, so only
+// material changes invalidate a learner's progress.
+func (c Concept) ContentVersion() string {
+	h := sha256.New()
+	write := func(s string) {
+		h.Write([]byte(strings.TrimSpace(s)))
+		h.Write([]byte{0})
+	}
+	write(c.Instruction)
+	write(c.ExpectedOutput)
+	for _, tc := range c.TestCases {
+		write(tc.Input)
+		write(tc.Expected)
+	}
+	switch c.Kind {
+	case KindPredict:
+		write(c.Answer)
+	case KindFix:
+		write(c.Boilerplate)
+		write(strconv.Itoa(c.MaxLinesChanged))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go-concept-trainer/concepts"
+)
+
+const (
+	defaultExamSize       = 5
+	maxExamSize           = 20
+	examMinutesPerConcept = 3
+	maxExamMinutes        = 180
+
+	// examRetention is how long after its deadline an exam's report is
+	// kept.
+	examRetention = 24 * time.Hour
+	// maxExams bounds the exams held at once.
+	maxExams = 10000
+)
+
+// examConcept is what an exam shows of a concept: the task without its
+// answer, tests or hints.
+type examConcept struct {
+	ID              string `json:"id"`
+	Number          int    `json:"number"`
+	Name            string `json:"name"`
+	Category        string `json:"category"`
+	Difficulty      string `json:"difficulty"`
+	Kind            string `json:"kind"`
+	Instruction     string `json:"instruction"`
+	Boilerplate     string `json:"boilerplate"`
+	MaxLinesChanged int    `json:"maxLinesChanged,omitempty"`
+	Seed            uint64 `json:"-"` // the variant drawn for this exam
+}
+
+// examSubmission is one graded attempt at an exam concept.
+type examSubmission struct {
+	Concept string    `json:"concept"`
+	At      time.Time `json:"at"`
+	Correct bool      `json:"correct"`
+}
+
+// exam is a learner's timed exam session. Its clock is the server's, so
+// reloading the page does not reset it.
+type exam struct {
+	StartedAt   time.Time        `json:"startedAt"`
+	Deadline    time.Time        `json:"deadline"`
+	FinishedAt  time.Time        `json:"finishedAt,omitzero"`
+	Concepts    []examConcept    `json:"concepts"`
+	Submissions []examSubmission `json:"submissions"`
+}
+
+func (e *exam) active(now time.Time) bool {
+	return e.FinishedAt.IsZero() && now.Before(e.Deadline)
+}
+
+// finish ends the exam at now, or at the deadline if that passed first.
+func (e *exam) finish(now time.Time) {
+	if e.FinishedAt.IsZero() {
+		e.FinishedAt = now
+		if now.After(e.Deadline) {
+			e.FinishedAt = e.Deadline
+		}
+	}
+}
+
+func (e *exam) concept(id string) (examConcept, bool) {
+	i := slices.IndexFunc(e.Concepts, func(c examConcept) bool { return c.ID == id })
+	if i < 0 {
+		return examConcept{}, false
+	}
+	return e.Concepts[i], true
+}
+
+// examResult is how a learner did on one exam concept.
+type examResult struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Solved   bool   `json:"solved"`
+	Attempts int    `json:"attempts"`
+	// SolvedAfter is seconds from the start of the exam to the first
+	// correct submission.
+	SolvedAfter int `json:"solvedAfter,omitempty"`
+}
+
+// examReport scores a finished exam: one point per concept solved.
+type examReport struct {
+	Score    int          `json:"score"`
+	Total    int          `json:"total"`
+	Duration int          `json:"duration"` // seconds from start to finish
+	Results  []examResult `json:"results"`
+}
+
+func (e *exam) report() *examReport {
+	rep := &examReport{Total: len(e.Concepts), Duration: int(e.FinishedAt.Sub(e.StartedAt).Seconds())}
+	for _, c := range e.Concepts {
+		res := examResult{ID: c.ID, Name: c.Name}
+		for _, s := range e.Submissions {
+			if s.Concept != c.ID || res.Solved {
+				continue
+			}
+			res.Attempts++
+			if s.Correct {
+				res.Solved = true
+				res.SolvedAfter = int(s.At.Sub(e.StartedAt).Seconds())
+				rep.Score++
+			}
+		}
+		rep.Results = append(rep.Results, res)
+	}
+	return rep
+}
+
+// examState is the response of the exam endpoints.
+type examState struct {
+	*exam
+	Active    bool        `json:"active"`
+	Remaining int         `json:"remaining"` // seconds left; the browser's clock may differ
+	Report    *examReport `json:"report,omitempty"`
+}
+
+// examStore holds each learner's latest exam in memory, until
+// examRetention after its deadline.
+type examStore struct {
+	mu    sync.Mutex
+	exams map[string]*exam
+}
+
+func newExamStore() *examStore {
+	s := &examStore{exams: make(map[string]*exam)}
+	go func() {
+		for range time.Tick(time.Hour) {
+			s.mu.Lock()
+			now := time.Now()
+			maps.DeleteFunc(s.exams, func(_ string, e *exam) bool {
+				return now.Sub(e.Deadline) > examRetention
+			})
+			s.mu.Unlock()
+		}
+	}()
+	return s
+}
+
+// state returns the learner's latest exam, finishing it if its time is up.
+// The caller holds s.mu.
+func (s *examStore) state(learner string, now time.Time) (examState, bool) {
+	e, ok := s.exams[learner]
+	if !ok {
+		return examState{}, false
+	}
+	if !e.active(now) {
+		e.finish(now)
+		return examState{exam: e, Report: e.report()}, true
+	}
+	return examState{exam: e, Active: true, Remaining: int(e.Deadline.Sub(now).Seconds())}, true
+}
+
+// activeConcept reports whether the learner is sitting an exam that
+// includes the concept, which hides its hints and answer.
+func (s *examStore) activeConcept(r *http.Request, id string) bool {
+	return s.activeConcepts(r)[id]
+}
+
+// activeConcepts returns the IDs of the concepts in the learner's exam in
+// progress, or nil when there is none.
+func (s *examStore) activeConcepts(r *http.Request) map[string]bool {
+	if s == nil {
+		return nil
+	}
+	learner, ok := learnerID(r)
+	if !ok {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.exams[learner]
+	if !ok || !e.active(time.Now()) {
+		return nil
+	}
+	ids := make(map[string]bool, len(e.Concepts))
+	for _, c := range e.Concepts {
+		ids[c.ID] = true
+	}
+	return ids
+}
+
+// forExam is what the concept APIs show of a concept in the learner's exam
+// in progress: like examConcept, neither its answer nor its tests, and for
+// predict concepts not the output either.
+func forExam(c Concept) Concept {
+	c.Answer, c.TestCases = "", nil
+	if c.Kind == concepts.KindPredict {
+		c.ExpectedOutput = ""
+	}
+	return c
+}
+
+// examAPI serves timed exams.
+type examAPI struct {
+	idx    *conceptIndex
+	grader *grader
+	store  *examStore
+}
+
+// examRequest is the body of POST /api/exam. Empty filters allow every
+// difficulty and category.
+type examRequest struct {
+	Count        int      `json:"count"`
+	Minutes      int      `json:"minutes"`
+	Difficulties []string `json:"difficulties"`
+	Categories   []string `json:"categories"`
+}
+
+// start serves POST /api/exam. It draws the concepts, spread across the
+// categories and difficulties allowed, and starts the clock. A learner
+// sits one exam at a time.
+func (a *examAPI) start(w http.ResponseWriter, r *http.Request) {
+	var req examRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.Count == 0 {
+		req.Count = defaultExamSize
+	}
+	if req.Count < 1 || req.Count > maxExamSize {
+		writeError(w, http.StatusBadRequest, "count must be between 1 and 20")
+		return
+	}
+	if req.Minutes == 0 {
+		req.Minutes = req.Count * examMinutesPerConcept
+	}
+	if req.Minutes < 1 || req.Minutes > maxExamMinutes {
+		writeError(w, http.StatusBadRequest, "minutes must be between 1 and 180")
+		return
+	}
+	if a.grader.runner == nil {
+		// Exams are graded on the server only.
+		writeError(w, http.StatusServiceUnavailable, errNoRunner.Error())
+		return
+	}
+	drawn := a.draw(req)
+	if len(drawn) == 0 {
+		writeError(w, http.StatusUnprocessableEntity, "no concepts match those filters")
+		return
+	}
+
+	learner := ensureLearner(w, r)
+	now := time.Now().UTC()
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+	if cur, ok := a.store.state(learner, now); ok && cur.Active {
+		writeJSON(w, http.StatusConflict, map[string]any{"error": "an exam is already in progress", "exam": cur})
+		return
+	}
+	if _, ok := a.store.exams[learner]; !ok && len(a.store.exams) >= maxExams {
+		writeError(w, http.StatusServiceUnavailable, "too many exams in progress, try again later")
+		return
+	}
+	e := &exam{
+		StartedAt:   now,
+		Deadline:    now.Add(time.Duration(req.Minutes) * time.Minute),
+		Concepts:    drawn,
+		Submissions: []examSubmission{},
+	}
+	a.store.exams[learner] = e
+	st, _ := a.store.state(learner, now)
+	writeJSON(w, http.StatusCreated, st)
+}
+
+// draw picks up to req.Count concepts, taking one category at a time in
+// random order and cycling through the allowed difficulties, so an exam
+// covers as much ground as it can. Parameterised concepts get a fresh
+// variant.
+func (a *examAPI) draw(req examRequest) []examConcept {
+	allowed := func(list []string, v string) bool {
+		return len(list) == 0 || slices.ContainsFunc(list, func(s string) bool { return strings.EqualFold(s, v) })
+	}
+	pools := make(map[string][]*Concept)
+	var categories []string
+	for i := range a.idx.list {
+		c := &a.idx.list[i]
+		if !allowed(req.Difficulties, c.Difficulty) || !allowed(req.Categories, c.Category) {
+			continue
+		}
+		if _, ok := pools[c.Category]; !ok {
+			categories = append(categories, c.Category)
+		}
+		pools[c.Category] = append(pools[c.Category], c)
+	}
+	rand.Shuffle(len(categories), func(i, j int) { categories[i], categories[j] = categories[j], categories[i] })
+	for _, pool := range pools {
+		rand.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+	}
+	levels := slices.DeleteFunc([]string{"beginner", "intermediate", "advanced"}, func(l string) bool {
+		return !allowed(req.Difficulties, l)
+	})
+
+	var drawn []examConcept
+	for len(drawn) < req.Count {
+		before := len(drawn)
+		for _, cat := range categories {
+			pool := pools[cat]
+			if len(pool) == 0 || len(drawn) == req.Count {
+				continue
+			}
+			want := levels[len(drawn)%len(levels)]
+			k := max(0, slices.IndexFunc(pool, func(c *Concept) bool { return c.Difficulty == want }))
+			c := pool[k]
+			pools[cat] = slices.Delete(pool, k, k+1)
+
+			var seed uint64
+			if c.Variants {
+				seed = newSeed()
+			}
+			v := instantiate(c, seed)
+			drawn = append(drawn, examConcept{
+				ID:              v.ID,
+				Number:          v.Number,
+				Name:            v.Name,
+				Category:        v.Category,
+				Difficulty:      v.Difficulty,
+				Kind:            v.Kind,
+				Instruction:     v.Instruction,
+				Boilerplate:     v.Boilerplate,
+				MaxLinesChanged: v.MaxLinesChanged,
+				Seed:            seed,
+			})
+		}
+		if len(drawn) == before {
+			break // every pool is empty
+		}
+	}
+	return drawn
+}
+
+// current serves GET /api/exam: the learner's exam in progress, or the
+// report of the last one.
+func (a *examAPI) current(w http.ResponseWriter, r *http.Request) {
+	learner, ok := learnerID(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "no exam")
+		return
+	}
+	a.store.mu.Lock()
+	st, ok := a.store.state(learner, time.Now().UTC())
+	a.store.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "no exam")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, st)
+}
+
+// examSubmitRequest is the body of POST /api/exam/submit: code, or for a
+// predict concept the predicted output.
+type examSubmitRequest struct {
+	Concept string `json:"concept"`
+	Code    string `json:"code"`
+	Output  string `json:"output"`
+}
+
+// submit serves POST /api/exam/submit. It grades the submission on the
+// server and records it with the time it arrived. Submissions after the
+// deadline are refused. The response says whether it passed and what the
+// program printed, but not what was expected.
+func (a *examAPI) submit(w http.ResponseWriter, r *http.Request) {
+	at := time.Now().UTC()
+	var req examSubmitRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	learner, ok := learnerID(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "no exam in progress")
+		return
+	}
+	a.store.mu.Lock()
+	st, ok := a.store.state(learner, at)
+	a.store.mu.Unlock()
+	if !ok || !st.Active {
+		writeError(w, http.StatusConflict, "no exam in progress")
+		return
+	}
+	ec, ok := st.concept(req.Concept)
+	if !ok {
+		writeError(w, http.StatusNotFound, "concept is not part of this exam")
+		return
+	}
+
+	res, err := a.grader.check(r.Context(), &a.idx.list[a.idx.byID[ec.ID]], gradeRequest{Code: req.Code, Output: req.Output, Seed: ec.Seed})
+	if err != nil {
+		a.grader.runFailed(w, r, err)
+		return
+	}
+	// Predict results would give the answer away line by line.
+	res.MatchingLines, res.ExpectedLines = 0, 0
+
+	a.store.mu.Lock()
+	st.Submissions = append(st.Submissions, examSubmission{Concept: ec.ID, At: at, Correct: res.Correct})
+	a.store.mu.Unlock()
+	writeJSON(w, http.StatusOK, res)
+}
+
+// finish serves POST /api/exam/finish: it ends the exam early and returns
+// the report.
+func (a *examAPI) finish(w http.ResponseWriter, r *http.Request) {
+	learner, ok := learnerID(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "no exam")
+		return
+	}
+	now := time.Now().UTC()
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+	e, ok := a.store.exams[learner]
+	if !ok {
+		writeError(w, http.StatusNotFound, "no exam")
+		return
+	}
+	e.finish(now)
+	st, _ := a.store.state(learner, now)
+	writeJSON(w, http.StatusOK, st)
+}
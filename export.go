@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-concept-trainer/anki"
+	"go-concept-trainer/cheatsheet"
+	"go-concept-trainer/concepts"
+)
+
+const ankiCSS = `.card { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; font-size: 18px; text-align: left; color: #1e1e1e; background: #fff; }
+.name { font-weight: bold; }
+.description { color: #666; }
+pre, code { font-family: Menlo, Consolas, 'DejaVu Sans Mono', monospace; font-size: 15px; }
+pre { background: #f5f5f5; padding: 8px; border-radius: 4px; white-space: pre-wrap; }
+.explanation { margin-top: 12px; }`
+
+// errExamExport refuses exports while the learner sits an exam, since they
+// cover every concept, including the exam's.
+const errExamExport = "exports are disabled during an exam"
+
+// ankiTag turns a category or difficulty into an Anki tag, which may not
+// contain spaces.
+func ankiTag(s string) string {
+	s = strings.ReplaceAll(s, " & ", "_and_")
+	return strings.Join(strings.Fields(s), "_")
+}
+
+// ankiNote renders a concept as a card: the exercise on the front, with
+// the program to read or fix, and on the back the reference answer and
+// explanation. For a predict concept the answer is output, what its program
+// prints; it is left out when the server cannot run the program.
+func ankiNote(c Concept, output string) anki.Note {
+	front := fmt.Sprintf(`<div class="name">%s</div><div class="description">%s</div><p>%s</p>`,
+		html.EscapeString(c.Name), html.EscapeString(c.Description), html.EscapeString(c.Instruction))
+	if p := c.Program(); p != "" {
+		front += fmt.Sprintf(`<pre><code>%s</code></pre>`, html.EscapeString(p))
+	}
+	var back string
+	if c.Kind == concepts.KindPredict {
+		if output != "" {
+			back = fmt.Sprintf(`<div>It prints:</div><pre><code>%s</code></pre>`, html.EscapeString(output))
+		}
+	} else {
+		back = fmt.Sprintf(`<pre><code>%s</code></pre>`, html.EscapeString(c.Answer))
+		if c.ExpectedOutput != "" {
+			back += fmt.Sprintf(`<div>Output:</div><pre><code>%s</code></pre>`, html.EscapeString(c.ExpectedOutput))
+		}
+	}
+	if c.Explanation != "" {
+		back += fmt.Sprintf(`<div class="explanation">%s</div>`, html.EscapeString(c.Explanation))
+	}
+	return anki.Note{
+		Key:   c.ID,
+		Front: front,
+		Back:  back,
+		Tags:  []string{ankiTag(c.Category), ankiTag(c.Difficulty)},
+	}
+}
+
+// exportAnki serves GET /api/export/anki: an .apkg deck of the concepts,
+// optionally narrowed with the same filters as GET /api/concepts.
+func (idx *conceptIndex) exportAnki(w http.ResponseWriter, r *http.Request) {
+	if idx.exams.activeConcepts(r) != nil {
+		writeError(w, http.StatusForbidden, errExamExport)
+		return
+	}
+	filter := parseConceptFilter(r)
+	deck := anki.Deck{
+		Name:        "Clanker Rehab: Go Concepts",
+		Description: "Go fundamentals from Clanker Rehab. Re-import to update cards in place.",
+		CSS:         ankiCSS,
+	}
+	for i := range idx.list {
+		c := &idx.list[i]
+		if !filter.match(c) {
+			continue
+		}
+		var output string
+		if c.Kind == concepts.KindPredict {
+			out, err := idx.grader.programOutput(r.Context(), c)
+			if err != nil && !errors.Is(err, errNoRunner) {
+				idx.grader.runFailed(w, r, err)
+				return
+			}
+			output = out
+		}
+		deck.Notes = append(deck.Notes, ankiNote(*c, output))
+	}
+	if len(deck.Notes) == 0 {
+		writeError(w, http.StatusNotFound, "no concepts match the filter")
+		return
+	}
+
+	// Build the package before writing anything, so a failure is a clean
+	// error rather than a truncated download.
+	var buf bytes.Buffer
+	if err := deck.WritePackage(&buf, time.Now()); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to build deck")
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="clanker-rehab.apkg"`)
+	w.Write(buf.Bytes())
+}
+
+// cheatsheetFormats maps the format query parameter to a renderer, its
+// content type and file extension.
+var cheatsheetFormats = map[string]struct {
+	render      func(io.Writer, string, []cheatsheet.Section) error
+	contentType string
+	ext         string
+}{
+	"md":   {cheatsheet.Markdown, "text/markdown; charset=utf-8", "md"},
+	"html": {cheatsheet.HTML, "text/html; charset=utf-8", "html"},
+	"pdf":  {cheatsheet.PDF, "application/pdf", "pdf"},
+}
+
+// exportCheatsheet serves GET /api/export/cheatsheet?format=md|html|pdf: a
+// printable reference of the concepts, narrowed with the same filters as
+// GET /api/concepts. HTML is shown inline so it can be printed; the other
+// formats download.
+func (idx *conceptIndex) exportCheatsheet(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = "html"
+	}
+	if idx.exams.activeConcepts(r) != nil {
+		writeError(w, http.StatusForbidden, errExamExport)
+		return
+	}
+	format, ok := cheatsheetFormats[name]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "format must be md, html or pdf")
+		return
+	}
+
+	filter := parseConceptFilter(r)
+	var selected []concepts.Concept
+	for _, c := range concepts.GetAll() {
+		if i, ok := idx.byID[c.ID]; ok && filter.match(&idx.list[i]) {
+			selected = append(selected, c)
+		}
+	}
+	if len(selected) == 0 {
+		writeError(w, http.StatusNotFound, "no concepts match the filter")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := format.render(&buf, cheatsheet.DefaultTitle, cheatsheet.Group(selected)); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to render cheat sheet")
+		return
+	}
+	w.Header().Set("Content-Type", format.contentType)
+	if name != "html" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="clanker-rehab-cheatsheet.%s"`, format.ext))
+	}
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go-concept-trainer/concepts"
+	"go-concept-trainer/runner"
+)
+
+// grader checks submissions on the server by running programs through the
+// sandbox runner.
+type grader struct {
+	idx    *conceptIndex
+	runner *runner.Runner // nil when no sandbox-runner is installed
+
+	mu      sync.Mutex
+	outputs map[string]string // concept ID@seed -> what its Answer prints
+}
+
+// maxCachedOutputs bounds the output cache. Variants are per learner, so
+// without a bound it would grow with every attempt.
+const maxCachedOutputs = 4096
+
+func newGrader(idx *conceptIndex, r *runner.Runner) *grader {
+	return &grader{idx: idx, runner: r, outputs: make(map[string]string)}
+}
+
+var errNoRunner = errors.New("code execution is not available on this server")
+
+// programOutput runs a concept's Answer once and remembers what it printed:
+// the expected output of predict concepts and of variants. Concepts are
+// compiled in, so the result holds for the process.
+func (g *grader) programOutput(ctx context.Context, c *Concept) (string, error) {
+	key := c.ID + "@" + strconv.FormatUint(c.Seed, 10)
+	g.mu.Lock()
+	out, ok := g.outputs[key]
+	g.mu.Unlock()
+	if ok {
+		return out, nil
+	}
+	if g.runner == nil {
+		return "", errNoRunner
+	}
+	res, err := g.runner.Run(ctx, c.Answer)
+	if err != nil {
+		return "", err
+	}
+	if res.Error != "" {
+		// A reference program that fails is a content bug, not the learner's.
+		slog.ErrorContext(ctx, "reference program failed", "concept", c.ID, "seed", c.Seed, "err", res.Error)
+		return "", errors.New("the exercise program failed to run")
+	}
+	out = normalizeOutput(res.Output)
+	g.mu.Lock()
+	if len(g.outputs) >= maxCachedOutputs {
+		clear(g.outputs)
+	}
+	g.outputs[key] = out
+	g.mu.Unlock()
+	return out, nil
+}
+
+// instantiate returns the variant of a parameterised concept for seed,
+// without its ExpectedOutput. Other concepts, and seed 0, give c itself.
+func instantiate(c *Concept, seed uint64) *Concept {
+	if !c.Variants || seed == 0 {
+		return c
+	}
+	v := newConcept(c.Registered.Variant(seed))
+	v.Seed = seed
+	return &v
+}
+
+// variant is instantiate with the ExpectedOutput computed by running the
+// variant's Answer.
+func (g *grader) variant(ctx context.Context, c *Concept, seed uint64) (*Concept, error) {
+	v := instantiate(c, seed)
+	if v == c || v.Kind == concepts.KindPredict {
+		return v, nil
+	}
+	out, err := g.programOutput(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	v.ExpectedOutput = out
+	return v, nil
+}
+
+// normalizeOutput drops trailing spaces on each line and blank lines at
+// either end, the differences a learner cannot see.
+func normalizeOutput(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// gradeRequest is the body of POST /api/grade/{id}. Predict concepts take
+// output; the others take code. Seed selects the variant of a
+// parameterised concept.
+type gradeRequest struct {
+	Output string `json:"output"`
+	Code   string `json:"code"`
+	Seed   uint64 `json:"seed"`
+}
+
+type gradeResult struct {
+	Kind    string `json:"kind"`
+	Correct bool   `json:"correct"`
+	// Predict: how many leading lines of the prediction were right.
+	MatchingLines int `json:"matchingLines,omitempty"`
+	ExpectedLines int `json:"expectedLines,omitempty"`
+	// Fix: the size of the submitted diff from the starting program.
+	LinesChanged    int `json:"linesChanged,omitempty"`
+	MaxLinesChanged int `json:"maxLinesChanged,omitempty"`
+	// Write and fix: what the submitted program did.
+	Output   string `json:"output,omitempty"`
+	RunError string `json:"runError,omitempty"`
+}
+
+// grade serves POST /api/grade/{id}; see check.
+func (g *grader) grade(w http.ResponseWriter, r *http.Request) {
+	i, ok := g.idx.byID[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "concept not found")
+		return
+	}
+	var req gradeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	res, err := g.check(r.Context(), &g.idx.list[i], req)
+	if err != nil {
+		g.runFailed(w, r, err)
+		return
+	}
+	if g.idx.exams.activeConcept(r, g.idx.list[i].ID) {
+		// As in exam submissions: predict results would give the answer
+		// away line by line.
+		res.MatchingLines, res.ExpectedLines = 0, 0
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+var errNoCode = errors.New("code is required")
+
+// check grades a submission for c. For predict-the-output concepts it
+// compares the learner's prediction with what the program really prints,
+// reporting how many lines matched but never the expected text. For write
+// concepts it runs the submitted code and compares its output with the
+// concept's ExpectedOutput. Fix concepts also report how many lines the
+// submission changed from the starting program; a fix that changes nothing
+// or more than MaxLinesChanged lines fails without being run.
+func (g *grader) check(ctx context.Context, c *Concept, req gradeRequest) (gradeResult, error) {
+	c, err := g.variant(ctx, c, req.Seed)
+	if err != nil {
+		return gradeResult{}, err
+	}
+
+	res := gradeResult{Kind: c.Kind}
+	switch c.Kind {
+	case concepts.KindPredict:
+		want, err := g.programOutput(ctx, c)
+		if err != nil {
+			return gradeResult{}, err
+		}
+		got := normalizeOutput(req.Output)
+		res.Correct = got == want
+		wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+		res.ExpectedLines = len(wantLines)
+		for res.MatchingLines < min(len(wantLines), len(gotLines)) && wantLines[res.MatchingLines] == gotLines[res.MatchingLines] {
+			res.MatchingLines++
+		}
+	default:
+		if req.Code == "" {
+			return gradeResult{}, errNoCode
+		}
+		if c.Kind == concepts.KindFix {
+			res.LinesChanged = concepts.LinesChanged(c.Boilerplate, req.Code)
+			res.MaxLinesChanged = c.MaxLinesChanged
+			if res.LinesChanged == 0 || res.LinesChanged > c.MaxLinesChanged {
+				return res, nil
+			}
+		}
+		if g.runner == nil {
+			return gradeResult{}, errNoRunner
+		}
+		run, err := g.runner.Run(ctx, req.Code)
+		if err != nil {
+			return gradeResult{}, err
+		}
+		res.Output, res.RunError = run.Output, run.Error
+		res.Correct = run.Error == "" && normalizeOutput(run.Output) == normalizeOutput(c.ExpectedOutput)
+	}
+	return res, nil
+}
+
+func (g *grader) runFailed(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, errNoCode):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, errNoRunner):
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, runner.ErrBusy):
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusServiceUnavailable, "too many programs running, try again")
+	default:
+		slog.ErrorContext(r.Context(), "grading run failed", "err", err)
+		writeError(w, http.StatusInternalServerError, "could not run the program")
+	}
+}
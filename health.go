@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"sync/atomic"
+)
+
+// readiness reports whether the server is fit to receive traffic. It runs a
+// fixed set of checks on every probe and reports not-ready once shutdown has
+// begun so the proxy stops routing new requests while in-flight ones drain.
+type readiness struct {
+	draining atomic.Bool
+	checks   []readinessCheck
+}
+
+type readinessCheck struct {
+	name string
+	fn   func() error
+}
+
+func newReadiness(allConcepts []Concept, site *assets) *readiness {
+	return &readiness{checks: []readinessCheck{
+		{"concepts", func() error {
+			if len(allConcepts) == 0 {
+				return errors.New("no concepts loaded")
+			}
+			return nil
+		}},
+		{"template", func() error {
+			_, err := site.templates()
+			return err
+		}},
+		{"wasm", func() error {
+			fi, err := fs.Stat(site.static, "yaegi.wasm")
+			if err != nil {
+				return err
+			}
+			if fi.Size() == 0 {
+				return errors.New("yaegi.wasm is empty")
+			}
+			return nil
+		}},
+	}}
+}
+
+// healthz is the liveness probe: if the process can answer, it is alive.
+func healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write([]byte("ok\n"))
+}
+
+// readyz is the readiness probe. It responds 503 with the failing checks when
+// any check fails or the server is draining.
+func (rd *readiness) readyz(w http.ResponseWriter, r *http.Request) {
+	status := http.StatusOK
+	results := make(map[string]string, len(rd.checks)+1)
+	for _, c := range rd.checks {
+		if err := c.fn(); err != nil {
+			results[c.name] = err.Error()
+			status = http.StatusServiceUnavailable
+		} else {
+			results[c.name] = "ok"
+		}
+	}
+	if rd.draining.Load() {
+		results["shutdown"] = "draining"
+		status = http.StatusServiceUnavailable
+	}
+
+	body := struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}{Status: "ok", Checks: results}
+	if status != http.StatusOK {
+		body.Status = "unavailable"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+
+	"go-concept-trainer/concepts"
+	"go-concept-trainer/progress"
+)
+
+// hintState is the response of the hint endpoints: the hints revealed so
+// far in the current attempt and how many there are in all.
+type hintState struct {
+	Concept string          `json:"concept"`
+	Level   int             `json:"level"`
+	Levels  int             `json:"levels"`
+	Hints   []concepts.Hint `json:"hints"`
+}
+
+// hintState returns the first level hints. The answer hint is for the
+// learner's variant (seed) of a parameterised concept, and for a predict
+// concept it is the program's output, filled in by running it.
+func (p *progressAPI) hintState(ctx context.Context, c *Concept, level int, seed uint64) hintState {
+	level = min(level, len(c.Hints))
+	hints := c.Hints[:level]
+	if v := instantiate(c, seed); level > 0 && level == len(c.Hints) && (v != c || c.Kind == concepts.KindPredict) {
+		hints = slices.Clone(hints)
+		hints[level-1].Text = v.Answer
+		if c.Kind == concepts.KindPredict {
+			out, err := p.grader.programOutput(ctx, v)
+			if err != nil {
+				out = "The output is not available: " + err.Error()
+			}
+			hints[level-1].Text = out
+		}
+	}
+	return hintState{Concept: c.ID, Level: level, Levels: len(c.Hints), Hints: hints}
+}
+
+// hints serves GET /api/hints/{id}: the hints this learner has already
+// revealed for the concept, so a reload shows the same help.
+func (p *progressAPI) hints(w http.ResponseWriter, r *http.Request) {
+	i, ok := p.idx.byID[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "concept not found")
+		return
+	}
+	c := &p.idx.list[i]
+	if p.exams.activeConcept(r, c.ID) {
+		writeError(w, http.StatusForbidden, "hints are disabled during an exam")
+		return
+	}
+	var rec progress.Record
+	if learner, ok := learnerID(r); ok {
+		f, err := p.store.get(learner)
+		switch {
+		case err == nil:
+			rec = f.Concepts[c.ID]
+		case !errors.Is(err, os.ErrNotExist):
+			writeError(w, http.StatusInternalServerError, "could not read stored progress")
+			return
+		}
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, p.hintState(r.Context(), c, rec.HintLevel, rec.Seed))
+}
+
+// revealHint serves POST /api/hints/{id}/reveal: it reveals the next hint,
+// or every hint up to ?level=n, and records the level reached for
+// scheduling. Levels never go down within an attempt.
+func (p *progressAPI) revealHint(w http.ResponseWriter, r *http.Request) {
+	i, ok := p.idx.byID[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "concept not found")
+		return
+	}
+	c := &p.idx.list[i]
+	if p.exams.activeConcept(r, c.ID) {
+		writeError(w, http.StatusForbidden, "hints are disabled during an exam")
+		return
+	}
+	target := 0
+	if s := r.URL.Query().Get("level"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > len(c.Hints) {
+			writeError(w, http.StatusBadRequest, "level must be between 1 and "+strconv.Itoa(len(c.Hints)))
+			return
+		}
+		target = n
+	}
+
+	var level int
+	var seed uint64
+	err := p.store.update(ensureLearner(w, r), func(f *progress.File) error {
+		rec := f.Concepts[c.ID]
+		next := target
+		if next == 0 {
+			next = rec.HintLevel + 1
+		}
+		rec.HintLevel = min(max(rec.HintLevel, next), len(c.Hints))
+		level, seed = rec.HintLevel, rec.Seed
+		f.Concepts[c.ID] = rec
+		return nil
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "could not store progress")
+		return
+	}
+	writeJSON(w, http.StatusOK, p.hintState(r.Context(), c, level, seed))
+}
@@ -0,0 +1,238 @@
+// Package integrity reads the editor's event log for an attempt and
+// measures how the solution got there: typed, pasted or neither.
+//
+// The browser records every change to the editor with its time, kind and
+// text. Analyze turns a log into signals: how much was pasted, how much
+// was brought back by undo or redo, how much arrived in bursts faster than
+// anyone types, and how much of the starting text was not in the exercise
+// to begin with. A large paste means the solution is treated as assisted.
+package integrity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Event kinds.
+const (
+	OpInsert = "insert" // typed, including text that replaced a selection
+	OpPaste  = "paste"  // pasted or dropped
+	OpDelete = "delete"
+	OpUndo   = "undo" // undo or redo; its text was not typed just now
+	OpSet    = "set"  // the app replaced the text, as Reset and Show Answer do
+)
+
+const (
+	// MaxEvents bounds a log.
+	MaxEvents = 20000
+	// MaxText bounds the text a log may replay to, the most the grader
+	// accepts.
+	MaxText = 64 << 10
+	// LargePasteChars is the size of a single paste that makes a solution
+	// assisted.
+	LargePasteChars = 80
+	// MinPastedChars and MaxPasteRatio make it assisted when at least
+	// that much, and more than that share, of the text arrived untyped:
+	// pasted, restored by undo or redo, or unlogged.
+	MinPastedChars = 40
+	MaxPasteRatio  = 0.5
+
+	// BurstChars is the size of one typed insertion that counts as a
+	// burst: autocompletion of a whole line or injected keystrokes.
+	BurstChars = 16
+	// Typing faster than burstRate characters a second over at least
+	// burstRunChars characters, without pausing longer than burstGap
+	// milliseconds, also counts as a burst.
+	burstRate     = 20
+	burstRunChars = 40
+	burstGap      = 500
+)
+
+// Event is one change to the editor.
+type Event struct {
+	T       int64  `json:"t"`                 // milliseconds since the log started
+	Op      string `json:"op"`                // see the Op constants
+	From    [2]int `json:"from"`              // line and column where the change starts
+	To      [2]int `json:"to"`                // and where it ended before the change
+	Text    string `json:"text,omitempty"`    // inserted text
+	Removed int    `json:"removed,omitempty"` // characters removed
+}
+
+// Log is the editor's history for one attempt: the text it started from
+// and every change since.
+type Log struct {
+	Start  string  `json:"start"`
+	Events []Event `json:"events"`
+}
+
+// Validate checks the log's shape.
+func (l *Log) Validate() error {
+	if len(l.Events) > MaxEvents {
+		return fmt.Errorf("edit log has more than %d events", MaxEvents)
+	}
+	var last int64
+	for i, e := range l.Events {
+		switch e.Op {
+		case OpInsert, OpPaste, OpDelete, OpUndo, OpSet:
+		default:
+			return fmt.Errorf("event %d: unknown op %q", i, e.Op)
+		}
+		if e.T < last {
+			return fmt.Errorf("event %d: time goes backwards", i)
+		}
+		if e.Removed < 0 || e.From[0] < 0 || e.From[1] < 0 || e.To[0] < 0 || e.To[1] < 0 {
+			return fmt.Errorf("event %d: negative position or count", i)
+		}
+		last = e.T
+	}
+	if len(l.Events) == 0 && l.Start == "" {
+		return errors.New("edit log is empty")
+	}
+	return nil
+}
+
+// Replay applies the log's events to its starting text and returns the
+// editor's text at the end, which is the solution the log claims to have
+// produced. Positions are lines and columns as the browser counts them, in
+// UTF-16 code units.
+func (l *Log) Replay() (string, error) {
+	text := l.Start
+	for i, e := range l.Events {
+		if e.Op == OpSet {
+			text = e.Text
+			continue
+		}
+		from, ok := offset(text, e.From)
+		to, ok2 := offset(text, e.To)
+		if !ok || !ok2 || to < from {
+			return "", fmt.Errorf("event %d: range is outside the text", i)
+		}
+		text = text[:from] + e.Text + text[to:]
+		if len(text) > MaxText {
+			return "", fmt.Errorf("event %d: text is longer than %d bytes", i, MaxText)
+		}
+	}
+	return text, nil
+}
+
+// offset converts a position to a byte offset in text.
+func offset(text string, pos [2]int) (int, bool) {
+	start := 0
+	for range pos[0] {
+		i := strings.IndexByte(text[start:], '\n')
+		if i < 0 {
+			return 0, false
+		}
+		start += i + 1
+	}
+	end := len(text)
+	if i := strings.IndexByte(text[start:], '\n'); i >= 0 {
+		end = start + i
+	}
+	col := 0
+	for i, r := range text[start:end] {
+		if col == pos[1] {
+			return start + i, true
+		}
+		col += utf16.RuneLen(r)
+	}
+	return end, col == pos[1]
+}
+
+// Signals summarise a log.
+type Signals struct {
+	Typed        int     `json:"typed"`              // characters typed
+	Pasted       int     `json:"pasted"`             // characters pasted or dropped
+	Restored     int     `json:"restored,omitempty"` // inserted by undo or redo, including a paste from before a reset
+	Deleted      int     `json:"deleted"`
+	Unlogged     int     `json:"unlogged,omitempty"` // starting text not in the exercise
+	LargestPaste int     `json:"largestPaste,omitempty"`
+	PasteRatio   float64 `json:"pasteRatio"` // untyped share: (Pasted+Restored+Unlogged) / all inserted
+	Bursts       int     `json:"bursts,omitempty"`
+	DurationMs   int64   `json:"durationMs"`
+	LargePaste   bool    `json:"largePaste,omitempty"` // the solution counts as assisted
+}
+
+// Analyze computes the signals for l. base is the exercise's starting
+// program: lines of the starting text that are not in it, as when a draft
+// or an earlier solution was loaded, count as text that arrived without
+// being typed. When the app replaced the text, only what happened since
+// counts, from the replacement as the starting text.
+func Analyze(l Log, base string) Signals {
+	var s Signals
+	if len(l.Events) > 0 {
+		s.DurationMs = l.Events[len(l.Events)-1].T - l.Events[0].T
+	}
+	start, events := l.Start, l.Events
+	for i, e := range l.Events {
+		if e.Op == OpSet {
+			start, events = e.Text, l.Events[i+1:]
+		}
+	}
+	s.Unlogged = unlogged(start, base)
+
+	var runChars int
+	var runStart, prev int64 = -1, -1
+	endRun := func() {
+		if runChars >= burstRunChars {
+			secs := max(float64(prev-runStart)/1000, 0.001)
+			if float64(runChars)/secs > burstRate {
+				s.Bursts++
+			}
+		}
+		runChars, runStart = 0, -1
+	}
+	for _, e := range events {
+		n := utf8.RuneCountInString(e.Text)
+		s.Deleted += e.Removed
+		switch e.Op {
+		case OpInsert:
+			s.Typed += n
+			if n >= BurstChars {
+				s.Bursts++
+				continue
+			}
+			if runStart >= 0 && e.T-prev > burstGap {
+				endRun()
+			}
+			if runStart < 0 {
+				runStart = e.T
+			}
+			runChars += n
+			prev = e.T
+		case OpPaste:
+			s.Pasted += n
+			s.LargestPaste = max(s.LargestPaste, n)
+		case OpUndo:
+			s.Restored += n
+		}
+	}
+	endRun()
+
+	untyped := s.Pasted + s.Restored + s.Unlogged
+	if total := s.Typed + untyped; total > 0 {
+		s.PasteRatio = float64(untyped) / float64(total)
+	}
+	s.LargePaste = s.LargestPaste >= LargePasteChars || s.Unlogged >= LargePasteChars ||
+		untyped >= MinPastedChars && s.PasteRatio > MaxPasteRatio
+	return s
+}
+
+// unlogged counts the characters on lines of start that do not appear in
+// base.
+func unlogged(start, base string) int {
+	have := make(map[string]bool)
+	for line := range strings.SplitSeq(base, "\n") {
+		have[strings.TrimSpace(line)] = true
+	}
+	n := 0
+	for line := range strings.SplitSeq(start, "\n") {
+		if line = strings.TrimSpace(line); !have[line] {
+			n += utf8.RuneCountInString(line)
+		}
+	}
+	return n
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// Learners have no passwords: a random ID in a long-lived HttpOnly cookie
+// identifies the browser's server-side state (synced progress, attempts,
+// exams). Losing the cookie loses access, which is why progress export
+// exists.
+const (
+	learnerCookie    = "learner"
+	learnerIDLen     = 32 // hex characters
+	learnerCookieAge = 365 * 24 * time.Hour
+)
+
+// learnerID returns the caller's learner ID if it has a well-formed cookie.
+func learnerID(r *http.Request) (string, bool) {
+	c, err := r.Cookie(learnerCookie)
+	if err != nil || !validLearnerID(c.Value) {
+		return "", false
+	}
+	return c.Value, true
+}
+
+// ensureLearner returns the caller's learner ID, issuing a new one and
+// setting the cookie if it has none.
+func ensureLearner(w http.ResponseWriter, r *http.Request) string {
+	if id, ok := learnerID(r); ok {
+		return id
+	}
+	var b [learnerIDLen / 2]byte
+	rand.Read(b[:])
+	id := hex.EncodeToString(b[:])
+	http.SetCookie(w, &http.Cookie{
+		Name:     learnerCookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(learnerCookieAge / time.Second),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return id
+}
+
+// validLearnerID also guards file names in the on-disk stores.
+func validLearnerID(id string) bool {
+	if len(id) != learnerIDLen {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const logFilePrefix = "server-"
+
+// dailyLogFile is an io.Writer that appends to one file per UTC day in dir,
+// named server-YYYY-MM-DD.log, so retention can be enforced by date.
+type dailyLogFile struct {
+	dir string
+
+	mu   sync.Mutex
+	day  string
+	file *os.File
+}
+
+func newDailyLogFile(dir string) (*dailyLogFile, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &dailyLogFile{dir: dir}, nil
+}
+
+func (d *dailyLogFile) Write(b []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	day := time.Now().UTC().Format(time.DateOnly)
+	if d.file == nil || day != d.day {
+		if d.file != nil {
+			d.file.Close()
+		}
+		f, err := os.OpenFile(filepath.Join(d.dir, logFilePrefix+day+".log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+		if err != nil {
+			d.file = nil
+			return 0, err
+		}
+		d.file, d.day = f, day
+	}
+	return d.file.Write(b)
+}
+
+// pruneLogs removes daily log files whose date is more than retentionDays in
+// the past. Files not matching the naming scheme are left alone.
+func pruneLogs(dir string, retentionDays int, now time.Time) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	cutoff := now.UTC().AddDate(0, 0, -retentionDays).Format(time.DateOnly)
+	var errs []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, logFilePrefix) || !strings.HasSuffix(name, ".log") {
+			continue
+		}
+		day := strings.TrimSuffix(strings.TrimPrefix(name, logFilePrefix), ".log")
+		if _, err := time.Parse(time.DateOnly, day); err != nil || day >= cutoff {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		slog.Info("pruned log file", "file", name, "retention_days", retentionDays)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("pruning logs: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// startLogPruner prunes immediately and then hourly for the process lifetime.
+func startLogPruner(dir string, retentionDays int) {
+	prune := func() {
+		if err := pruneLogs(dir, retentionDays, time.Now()); err != nil {
+			slog.Warn("log retention failed", "err", err)
+		}
+	}
+	prune()
+	go func() {
+		for range time.Tick(time.Hour) {
+			prune()
+		}
+	}()
+}
@@ -1,23 +1,34 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"html/template"
-	"log"
+	"io"
+	"log/slog"
 	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"go-concept-trainer/concepts"
+	"go-concept-trainer/runner"
 )
 
 type Concept struct {
 	Number         int        `json:"number"`
 	ID             string     `json:"id"`
 	Category       string     `json:"category"`
+	Kind           string     `json:"kind"`
 	Name           string     `json:"name"`
 	Description    string     `json:"description"`
 	Instruction    string     `json:"instruction"`
@@ -32,6 +43,25 @@ type Concept struct {
 	Prerequisites  []string   `json:"prerequisites"`
 	RelatedTopics  []string   `json:"relatedTopics"`
 	DocsURL        string     `json:"docsUrl"`
+	Version        string     `json:"version"`
+
+	MaxLinesChanged int            `json:"maxLinesChanged,omitempty"` // fix concepts only
+	Quiz            []quizQuestion `json:"quiz,omitempty"`            // prompts and choices only
+	Variants        bool           `json:"variants,omitempty"`        // each attempt gets its own, from /api/variants
+	Seed            uint64         `json:"seed,omitempty"`            // which variant this is; 0 is the default
+
+	Hints      []concepts.Hint     `json:"-"` // the hint ladder, revealed one at a time by /api/hints
+	Questions  []concepts.Question `json:"-"` // the quiz with answers, graded by /api/quiz
+	Registered concepts.Concept    `json:"-"` // the source of variants
+}
+
+// Program is the code the exercise shows: the program to read for predict
+// concepts and the one to fix for fix concepts. Write concepts show none.
+func (c Concept) Program() string {
+	if c.Kind == concepts.KindPredict || c.Kind == concepts.KindFix {
+		return c.Boilerplate
+	}
+	return ""
 }
 
 type TestCase struct {
@@ -43,29 +73,42 @@ func getConcepts() []Concept {
 	pkgConcepts := concepts.GetAll()
 	result := make([]Concept, len(pkgConcepts))
 	for i, c := range pkgConcepts {
-		result[i] = Concept{
-			Number:         c.Number,
-			ID:             c.ID,
-			Category:       c.Category,
-			Name:           c.Name,
-			Description:    c.Description,
-			Instruction:    c.Instruction,
-			Boilerplate:    c.Boilerplate,
-			Answer:         c.Answer,
-			ExpectedOutput: c.ExpectedOutput,
-			TestCases:      convertTestCases(c.TestCases),
-			Difficulty:     c.Difficulty,
-			Explanation:    c.Explanation,
-			Example:        c.Example,
-			UseCase:        c.UseCase,
-			Prerequisites:  c.Prerequisites,
-			RelatedTopics:  c.RelatedTopics,
-			DocsURL:        c.DocsURL,
-		}
+		result[i] = newConcept(c)
 	}
 	return result
 }
 
+func newConcept(c concepts.Concept) Concept {
+	return Concept{
+		Number:         c.Number,
+		ID:             c.ID,
+		Category:       c.Category,
+		Kind:           c.Kind,
+		Name:           c.Name,
+		Description:    c.Description,
+		Instruction:    c.Instruction,
+		Boilerplate:    c.Boilerplate,
+		Answer:         c.Answer,
+		ExpectedOutput: c.ExpectedOutput,
+		TestCases:      convertTestCases(c.TestCases),
+		Difficulty:     c.Difficulty,
+		Explanation:    c.Explanation,
+		Example:        c.Example,
+		UseCase:        c.UseCase,
+		Prerequisites:  c.Prerequisites,
+		RelatedTopics:  c.RelatedTopics,
+		DocsURL:        c.DocsURL,
+		Version:        c.Version,
+		Hints:          c.HintLadder(),
+
+		MaxLinesChanged: c.MaxLinesChanged,
+		Quiz:            quizView(c.Quiz),
+		Variants:        len(c.Params) > 0,
+		Questions:       c.Quiz,
+		Registered:      c,
+	}
+}
+
 func convertTestCases(tcs []concepts.TestCase) []TestCase {
 	result := make([]TestCase, len(tcs))
 	for i, tc := range tcs {
@@ -79,8 +122,9 @@ func convertTestCases(tcs []concepts.TestCase) []TestCase {
 
 // ipLimiter is a simple fixed-window per-IP rate limiter.
 type ipLimiter struct {
-	mu      sync.Mutex
-	windows map[string]*ipWindow
+	mu       sync.Mutex
+	windows  map[string]*ipWindow
+	rejected atomic.Uint64 // requests refused since start, exported on /metrics
 }
 
 type ipWindow struct {
@@ -94,6 +138,20 @@ const (
 	maxBodyLen = 1 << 20       // 1 MB
 )
 
+const (
+	listenAddr        = ":8080"
+	readHeaderTimeout = 5 * time.Second
+	readTimeout       = 15 * time.Second
+	writeTimeout      = 60 * time.Second // long enough to stream yaegi.wasm to slow clients
+	idleTimeout       = 120 * time.Second
+	shutdownTimeout   = 20 * time.Second // drain deadline for in-flight requests
+	runTimeout        = 5 * time.Second  // wall clock per server-side program run
+	runWait           = 10 * time.Second // for a free runner slot before answering busy
+	runCPU            = 5 * time.Second  // CPU time per run
+	runMemory         = 256 << 20        // bytes of heap and other private memory per run
+	runThreads        = 64               // OS threads per run
+)
+
 func newIPLimiter() *ipLimiter {
 	l := &ipLimiter{windows: make(map[string]*ipWindow)}
 	go func() {
@@ -148,6 +206,7 @@ func rateLimitMiddleware(limiter *ipLimiter, next http.Handler) http.Handler {
 			ip = strings.TrimSpace(ip[:idx])
 		}
 		if !limiter.allow(ip) {
+			limiter.rejected.Add(1)
 			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
 			return
 		}
@@ -166,66 +225,252 @@ func requestLimiter(next http.Handler) http.Handler {
 	})
 }
 
+// fatal logs msg at error level and exits, replacing log.Fatalf.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
 func main() {
+	baseURL := flag.String("base-url", "", "public site URL used in sitemap.xml; without it /sitemap.xml is not served")
+	dataDir := flag.String("data-dir", "", "persist learner state (progress, attempts) under this directory; if empty it is kept in memory, for every learner, until the process exits")
+	dev := flag.Bool("dev", false, "serve templates and static files from the working directory for live editing")
+	logLevel := flag.String("log-level", "info", "minimum log level: debug, info, warn or error")
+	logFormat := flag.String("log-format", "json", "log output format: json or text")
+	logDir := flag.String("log-dir", "", "also write logs to daily files in this directory")
+	logRetention := flag.Int("log-retention-days", 30, "delete daily log files older than this many days (0 keeps them forever)")
+	logIP := flag.String("log-ip", "full", "client IP logging: full, truncate, hash or none")
+	logIPRotation := flag.Duration("log-ip-key-rotation", 24*time.Hour, "how often the IP hashing key rotates")
+	logUA := flag.String("log-ua", "full", "user agent logging: full, reduced or none")
+	logReferer := flag.String("log-referer", "full", "referer logging: full, strip (drop query string) or none")
+	shutdownDelay := flag.Duration("shutdown-delay", 5*time.Second, "how long /readyz reports draining before the listener closes, so load balancers stop routing here")
+	sandboxPath := flag.String("sandbox", "", "sandbox-runner binary for server-side grading (default: next to this binary, then PATH)")
+	flag.Parse()
+
+	// dataPath returns a subdirectory of -data-dir, or "" for in-memory stores.
+	dataPath := func(sub string) string {
+		if *dataDir == "" {
+			return ""
+		}
+		return filepath.Join(*dataDir, sub)
+	}
+
+	var logOut io.Writer = os.Stdout
+	if *logDir != "" {
+		f, err := newDailyLogFile(*logDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		logOut = io.MultiWriter(os.Stdout, f)
+	}
+	logger, err := newLogger(logOut, *logLevel, *logFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
+	if *logDir != "" && *logRetention > 0 {
+		startLogPruner(*logDir, *logRetention)
+	}
+
+	// The hashing secret comes from the environment so it stays out of
+	// process listings; without it a random per-process secret is used.
+	priv, err := newLogPrivacy(*logIP, *logUA, *logReferer, os.Getenv("LOG_IP_HASH_SECRET"), *logIPRotation)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	// Learner pseudonyms in run telemetry must stay stable for attempts to
+	// be grouped, so they use their own key that never rotates: from the
+	// environment, or kept in -data-dir.
+	priv.learnerKey, err = loadLearnerKey(os.Getenv("LOG_LEARNER_SECRET"), dataPath("learner-key"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "learner key:", err)
+		os.Exit(2)
+	}
+	if priv.learnerKey == nil {
+		slog.Info("run telemetry has no learner pseudonyms; set LOG_LEARNER_SECRET or -data-dir to enable them")
+	}
+
 	allConcepts := getConcepts()
-	fmt.Printf("Loaded %d concepts from concepts package\n", len(allConcepts))
+	slog.Info("loaded concepts", "count", len(allConcepts))
 
-	conceptsJSON, err := json.Marshal(allConcepts)
+	conceptIdx, err := newConceptIndex(allConcepts)
 	if err != nil {
-		log.Fatalf("Failed to marshal concepts: %v", err)
+		fatal("failed to index concepts", "err", err)
 	}
 
-	indexTmpl, err := template.ParseFiles("templates/index.html")
+	site, err := newAssets(*dev)
 	if err != nil {
-		log.Fatalf("Failed to parse template: %v", err)
+		fatal("failed to load templates and static assets", "err", err)
 	}
 
+	progressStore, err := newProgressStore(dataPath("progress"))
+	if err != nil {
+		fatal("failed to open progress store", "err", err)
+	}
+	replayStore, err := newReplayStore(dataPath("replays"))
+	if err != nil {
+		fatal("failed to open replay store", "err", err)
+	}
+
+	if *sandboxPath == "" {
+		*sandboxPath = runner.Find()
+	}
+	var run *runner.Runner
+	if *sandboxPath != "" {
+		run = runner.New(*sandboxPath, runtime.NumCPU(), runner.Limits{
+			Timeout: runTimeout,
+			Wait:    runWait,
+			CPU:     runCPU,
+			Memory:  runMemory,
+			Threads: runThreads,
+		})
+		slog.Info("server-side grading enabled", "sandbox", *sandboxPath)
+	} else {
+		slog.Warn("sandbox-runner not found; server-side grading is disabled")
+	}
+	grader := newGrader(conceptIdx, run)
+
 	limiter := newIPLimiter()
+	m := newMetrics(limiter)
 
 	mux := http.NewServeMux()
 
 	// Go 1.22+ method+path routing: non-matching methods get 405 automatically.
 	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
-		if err := indexTmpl.Execute(w, nil); err != nil {
-			log.Printf("Template execute error: %v", err)
-		}
-	})
-	mux.HandleFunc("GET /api/concepts", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.Write(conceptsJSON)
+		site.render(w, r, "index.html", nil)
 	})
+	pg := &pages{site: site, idx: conceptIdx, baseURL: strings.TrimSuffix(*baseURL, "/")}
+	mux.HandleFunc("GET /concepts/{$}", pg.indexPage)
+	mux.HandleFunc("GET /concepts/{id}", pg.conceptPage)
+	mux.HandleFunc("GET /sitemap.xml", pg.sitemap)
+	mux.HandleFunc("GET /api/concepts", conceptIdx.listConcepts)
+	mux.HandleFunc("GET /api/concepts/{id}", conceptIdx.getConcept)
+	mux.HandleFunc("GET /api/concepts/by-number/{n}", conceptIdx.getConceptByNumber)
+	mux.HandleFunc("GET /api/search", conceptIdx.searchConcepts)
+	mux.HandleFunc("GET /api/export/anki", conceptIdx.exportAnki)
+	mux.HandleFunc("GET /api/export/cheatsheet", conceptIdx.exportCheatsheet)
+	mux.HandleFunc("POST /api/progress/check", conceptIdx.checkProgress)
+	mux.HandleFunc("POST /api/grade/{id}", grader.grade)
+	exams := newExamStore()
+	conceptIdx.exams = exams
+	conceptIdx.grader = grader
+	progressAPI := &progressAPI{idx: conceptIdx, store: progressStore, grader: grader, exams: exams, sessions: newSessionStore(), replays: replayStore}
+	mux.HandleFunc("POST /api/progress/import", progressAPI.importProgress)
+	mux.HandleFunc("GET /api/progress/export", progressAPI.exportProgress)
+	mux.HandleFunc("POST /api/progress/{id}/learned", progressAPI.markLearned)
+	mux.HandleFunc("POST /api/quiz/{id}/answer", progressAPI.answerQuiz)
+	mux.HandleFunc("GET /api/variants/{id}", progressAPI.variant)
+	mux.HandleFunc("GET /api/session/today", progressAPI.today)
+	mux.HandleFunc("GET /api/mastery", progressAPI.assess)
+	mux.HandleFunc("GET /api/replay/{id}", progressAPI.replay)
+	examAPI := &examAPI{idx: conceptIdx, grader: grader, store: exams}
+	mux.HandleFunc("POST /api/exam", examAPI.start)
+	mux.HandleFunc("GET /api/exam", examAPI.current)
+	mux.HandleFunc("POST /api/exam/submit", examAPI.submit)
+	mux.HandleFunc("POST /api/exam/finish", examAPI.finish)
+	mux.HandleFunc("GET /api/hints/{id}", progressAPI.hints)
+	mux.HandleFunc("POST /api/hints/{id}/reveal", progressAPI.revealHint)
+	// Runs that name their concept feed cmd/difficulty-report: outcome is
+	// pass, fail or error, revealed says the answer had been shown, and
+	// learner is a pseudonym that groups a learner's runs into attempts.
 	mux.HandleFunc("POST /api/log-run", func(w http.ResponseWriter, r *http.Request) {
 		var body struct {
-			ExitCode    int `json:"exit_code"`
-			DurationMs  int `json:"duration_ms"`
-			OutputBytes int `json:"output_bytes"`
+			ExitCode    int    `json:"exit_code"`
+			DurationMs  int    `json:"duration_ms"`
+			OutputBytes int    `json:"output_bytes"`
+			Concept     string `json:"concept"`
+			Outcome     string `json:"outcome"`
+			Revealed    bool   `json:"revealed"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 			http.Error(w, "bad request", http.StatusBadRequest)
 			return
 		}
-		entry := map[string]interface{}{
-			"ts":           time.Now().UTC().Format(time.RFC3339),
-			"event":        "code_execute",
-			"lang":         "go",
-			"exit_code":    body.ExitCode,
-			"duration_ms":  body.DurationMs,
-			"output_bytes": body.OutputBytes,
+		switch body.Outcome {
+		case "", "pass", "fail", "error":
+		default:
+			http.Error(w, "outcome must be pass, fail or error", http.StatusBadRequest)
+			return
 		}
-		b, _ := json.Marshal(entry)
-		fmt.Println(string(b))
-		w.WriteHeader(http.StatusNoContent)
-	})
-	mux.Handle("GET /static/", http.StripPrefix("/static/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "" || r.URL.Path[len(r.URL.Path)-1] == '/' {
-			http.NotFound(w, r)
+		if _, ok := conceptIdx.byID[body.Concept]; body.Concept != "" && !ok {
+			http.Error(w, "unknown concept", http.StatusBadRequest)
 			return
 		}
-		http.FileServer(http.Dir("static")).ServeHTTP(w, r)
-	})))
+		m.observeRun(body.ExitCode, body.DurationMs)
+		attrs := []any{
+			"lang", "go",
+			"exit_code", body.ExitCode,
+			"duration_ms", body.DurationMs,
+			"output_bytes", body.OutputBytes,
+		}
+		if body.Concept != "" {
+			attrs = append(attrs, "concept", body.Concept, "outcome", body.Outcome, "revealed", body.Revealed)
+			if id, ok := learnerID(r); ok {
+				if pseudonym, ok := priv.learner(id); ok {
+					attrs = append(attrs, "learner", pseudonym)
+				}
+			}
+		}
+		slog.InfoContext(r.Context(), "code_execute", attrs...)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.Handle("GET /static/", site.serveStatic())
+
+	handler := requestID(instrument(m, mux, accessLog(priv, securityHeaders(rateLimitMiddleware(limiter, requestLimiter(mux))))))
 
-	handler := accessLog(securityHeaders(rateLimitMiddleware(limiter, requestLimiter(mux))))
+	// Probes and scrapes bypass the middleware chain so they are neither rate
+	// limited nor written to the access log every few seconds. /metrics is
+	// expected to be blocked at the reverse proxy for public traffic.
+	ready := newReadiness(allConcepts, site)
+	root := http.NewServeMux()
+	root.HandleFunc("GET /healthz", healthz)
+	root.HandleFunc("GET /readyz", ready.readyz)
+	root.HandleFunc("GET /metrics", m.serveHTTP)
+	root.Handle("/", handler)
 
-	fmt.Println("Server running at http://localhost:8080")
-	log.Fatal(http.ListenAndServe(":8080", handler))
+	srv := &http.Server{
+		Addr:              listenAddr,
+		Handler:           root,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		slog.Info("server listening", "addr", listenAddr)
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		fatal("server error", "err", err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	// Fail readiness first and keep serving for a while, so load balancers
+	// see the 503 and stop routing here before the listener closes.
+	ready.draining.Store(true)
+	if *shutdownDelay > 0 {
+		slog.Info("draining, waiting for load balancers to notice", "delay", shutdownDelay.String())
+		time.Sleep(*shutdownDelay)
+	}
+	slog.Info("shutting down, draining in-flight requests", "deadline", shutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("graceful shutdown incomplete", "err", err)
+		srv.Close()
+	}
+	if err := <-errc; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("server error", "err", err)
+	}
 }
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"go-concept-trainer/mastery"
+	"go-concept-trainer/progress"
+)
+
+const (
+	defaultRecommendations = 3
+	maxRecommendations     = 20
+)
+
+// assess serves GET /api/mastery: the mastery model's estimate for every
+// concept and category from the learner's attempt history, and up to
+// ?limit= recommendations of what to practise next among the difficulties
+// in ?difficulty=, all of them if empty.
+func (p *progressAPI) assess(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, err := queryInt(q.Get("limit"), defaultRecommendations)
+	if err != nil || limit < 0 || limit > maxRecommendations {
+		writeError(w, http.StatusBadRequest, "limit must be between 0 and 20")
+		return
+	}
+	difficulties, err := parseDifficulties(q.Get("difficulty"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f := progress.New()
+	if learner, ok := learnerID(r); ok {
+		stored, err := p.store.get(learner)
+		switch {
+		case err == nil:
+			f = stored
+		case !errors.Is(err, os.ErrNotExist):
+			writeError(w, http.StatusInternalServerError, "could not read stored progress")
+			return
+		}
+	}
+
+	items := make([]mastery.Item, len(p.idx.list))
+	for i, c := range p.idx.list {
+		items[i] = mastery.Item{
+			ID:            c.ID,
+			Number:        c.Number,
+			Category:      c.Category,
+			Difficulty:    c.Difficulty,
+			Prerequisites: c.Prerequisites,
+			Version:       c.Version,
+		}
+	}
+	include := func(it mastery.Item) bool {
+		return len(difficulties) == 0 || slices.Contains(difficulties, it.Difficulty)
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, mastery.Default.Assess(items, f, time.Now().UTC(), limit, include))
+}
@@ -0,0 +1,212 @@
+// Package mastery estimates how well a learner knows each concept from
+// their attempt history, using Bayesian knowledge tracing, and recommends
+// what to practise next.
+//
+// Each attempt is a run of observations: one failure per extra try before
+// the pass, then the pass itself, which counts as a success only if it
+// needed no hints, explanation or quiz corrections. A review taken after it
+// was due first applies forgetting. The estimate is the probability that
+// the learner knows the concept; concepts never attempted have no evidence
+// and estimate 0.
+package mastery
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"go-concept-trainer/progress"
+)
+
+// Model holds the knowledge tracing parameters.
+type Model struct {
+	Init   float64 // chance the learner knew the concept before any attempt
+	Learn  float64 // chance of learning it from one observation
+	Slip   float64 // chance of failing although it is known
+	Guess  float64 // chance of passing cleanly although it is not
+	Forget float64 // chance of having forgotten it once a review is due
+}
+
+// Default are the parameters the server uses.
+var Default = Model{Init: 0.1, Learn: 0.3, Slip: 0.1, Guess: 0.2, Forget: 0.2}
+
+const (
+	// Mastered is the estimate from which a concept counts as mastered:
+	// about three clean passes in a row.
+	Mastered = 0.9
+	// Ready is the estimate a prerequisite needs before the model
+	// recommends building on it: about one clean pass.
+	Ready = 0.5
+	// maxFailures caps the failed tries one attempt contributes.
+	maxFailures = 3
+)
+
+// Item is what the model needs to know about a concept.
+type Item struct {
+	ID            string
+	Number        int
+	Category      string
+	Difficulty    string
+	Prerequisites []string
+	Version       string // the current content version
+}
+
+// Estimate is the model's view of one concept.
+type Estimate struct {
+	Mastery  float64 `json:"mastery"`
+	Attempts int     `json:"attempts"`
+	// Lapses counts reviews that did not pass cleanly.
+	Lapses   int  `json:"lapses,omitempty"`
+	Mastered bool `json:"mastered"`
+	// Due is set when the concept is learned but due for review, or
+	// changed since.
+	Due bool `json:"due,omitempty"`
+}
+
+// observe updates p after one observation and the chance of learning
+// that follows it.
+func (m Model) observe(p float64, correct bool) float64 {
+	if correct {
+		p = p * (1 - m.Slip) / (p*(1-m.Slip) + (1-p)*m.Guess)
+	} else {
+		p = p * m.Slip / (p*m.Slip + (1-p)*(1-m.Guess))
+	}
+	return p + (1-p)*m.Learn
+}
+
+// Concept estimates mastery of it from its record at now.
+func (m Model) Concept(it Item, rec progress.Record, now time.Time) Estimate {
+	attempts := rec.Attempts()
+	if len(attempts) == 0 {
+		return Estimate{}
+	}
+	e := Estimate{Attempts: len(attempts)}
+	p := m.Init
+	for _, a := range attempts {
+		if a.Overdue {
+			p *= 1 - m.Forget
+		}
+		for range min(max(a.Tries-1, 0), maxFailures) {
+			p = m.observe(p, false)
+		}
+		p = m.observe(p, a.Clean())
+		if a.Review && !a.Clean() {
+			e.Lapses++
+		}
+	}
+	if l := rec.Learned; l != nil {
+		e.Due = !now.Before(l.DueAt()) || l.Version != "" && l.Version != it.Version
+	}
+	if e.Due {
+		p *= 1 - m.Forget
+	}
+	e.Mastery = p
+	e.Mastered = p >= Mastered
+	return e
+}
+
+// Category is the mastery of one category: the mean estimate of its
+// concepts, counting those never attempted as 0.
+type Category struct {
+	Name     string  `json:"name"`
+	Mastery  float64 `json:"mastery"`
+	Concepts int     `json:"concepts"`
+	Mastered int     `json:"mastered"`
+}
+
+// Reasons for a recommendation.
+const (
+	ReasonReview     = "review"     // learned but due, weakest first
+	ReasonNew        = "new"        // its prerequisites are ready
+	ReasonStrengthen = "strengthen" // a shaky prerequisite of something new
+)
+
+// Recommendation is a concept to practise next.
+type Recommendation struct {
+	ID      string  `json:"id"`
+	Reason  string  `json:"reason"`
+	Mastery float64 `json:"mastery"`
+}
+
+// Report is the model's view of a learner.
+type Report struct {
+	Concepts   map[string]Estimate `json:"concepts"`
+	Categories []Category          `json:"categories"`
+	Next       []Recommendation    `json:"next"`
+}
+
+// Assess estimates every item from f at now and recommends up to limit
+// concepts among those include accepts; include nil accepts all. Items are
+// expected in curriculum order, which orders categories and new concepts.
+//
+// Due reviews come first, weakest first. Then come new concepts whose
+// prerequisites are all Ready, in curriculum order, and then learned
+// concepts below Ready that hold back a new one, weakest first.
+func (m Model) Assess(items []Item, f *progress.File, now time.Time, limit int, include func(Item) bool) *Report {
+	rep := &Report{Concepts: make(map[string]Estimate, len(items)), Categories: []Category{}, Next: []Recommendation{}}
+	cats := make(map[string]int)
+	for _, it := range items {
+		e := m.Concept(it, f.Concepts[it.ID], now)
+		rep.Concepts[it.ID] = e
+		i, ok := cats[it.Category]
+		if !ok {
+			i = len(rep.Categories)
+			cats[it.Category] = i
+			rep.Categories = append(rep.Categories, Category{Name: it.Category})
+		}
+		c := &rep.Categories[i]
+		c.Concepts++
+		c.Mastery += e.Mastery
+		if e.Mastered {
+			c.Mastered++
+		}
+	}
+	for i := range rep.Categories {
+		rep.Categories[i].Mastery /= float64(rep.Categories[i].Concepts)
+	}
+
+	known := make(map[string]bool, len(items))
+	for _, it := range items {
+		known[it.ID] = true
+	}
+	var reviews, fresh, shaky []Recommendation
+	holding := make(map[string]bool)
+	for _, it := range items {
+		if include != nil && !include(it) {
+			continue
+		}
+		e := rep.Concepts[it.ID]
+		learned := f.Concepts[it.ID].Learned != nil
+		switch {
+		case learned && e.Due:
+			reviews = append(reviews, Recommendation{it.ID, ReasonReview, e.Mastery})
+		case !learned:
+			ready := true
+			for _, id := range it.Prerequisites {
+				if !known[id] {
+					continue
+				}
+				if p := rep.Concepts[id]; p.Mastery < Ready {
+					ready = false
+					if f.Concepts[id].Learned != nil && !p.Due {
+						holding[id] = true
+					}
+				}
+			}
+			if ready {
+				fresh = append(fresh, Recommendation{it.ID, ReasonNew, e.Mastery})
+			}
+		}
+	}
+	for _, it := range items {
+		if holding[it.ID] && (include == nil || include(it)) {
+			shaky = append(shaky, Recommendation{it.ID, ReasonStrengthen, rep.Concepts[it.ID].Mastery})
+		}
+	}
+	weakest := func(a, b Recommendation) int { return cmp.Compare(a.Mastery, b.Mastery) }
+	slices.SortStableFunc(reviews, weakest)
+	slices.SortStableFunc(shaky, weakest)
+	next := slices.Concat(reviews, fresh, shaky)
+	rep.Next = append(rep.Next, next[:min(limit, len(next))]...)
+	return rep
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Latency buckets in seconds for HTTP requests and client-side code runs.
+var (
+	httpBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	runBuckets  = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2, 5}
+)
+
+// metrics holds every series exposed on /metrics. The exposition format is the
+// Prometheus text format, written by hand to keep the binary dependency-free.
+type metrics struct {
+	start time.Time
+
+	requests    *counterVec
+	latency     *histogramVec
+	flagged     *counterVec
+	runs        *counterVec
+	runDuration *histogramVec
+
+	// rateLimited is read from the limiter on scrape rather than counted here.
+	rateLimited func() uint64
+}
+
+func newMetrics(limiter *ipLimiter) *metrics {
+	return &metrics{
+		start:       time.Now(),
+		requests:    newCounterVec("http_requests_total", "HTTP requests by route, method and status.", "route", "method", "status"),
+		latency:     newHistogramVec("http_request_duration_seconds", "HTTP request latency by route and status.", httpBuckets, "route", "status"),
+		flagged:     newCounterVec("http_flagged_requests_total", "Requests flagged as suspicious, by flag.", "flag"),
+		runs:        newCounterVec("code_executions_total", "Code executions reported by clients, by exit code (0, 1 or other).", "exit_code"),
+		runDuration: newHistogramVec("code_execution_duration_seconds", "Client-reported code execution time by exit code.", runBuckets, "exit_code"),
+		rateLimited: limiter.rejected.Load,
+	}
+}
+
+// observeRun records a code execution reported through /api/log-run. The exit
+// code comes from the client, so it is bucketed to keep the series bounded.
+func (m *metrics) observeRun(exitCode, durationMs int) {
+	code := "other"
+	if exitCode == 0 || exitCode == 1 {
+		code = strconv.Itoa(exitCode)
+	}
+	m.runs.inc(code)
+	m.runDuration.observe(float64(durationMs)/1000, code)
+}
+
+// knownMethods are the request methods that get their own label value; any
+// other method is counted as "other".
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// instrument records request counts, latency and flags for every request that
+// passes through it. Routes are labelled with the ServeMux pattern and methods
+// outside a fixed set as "other", so that arbitrary probes cannot blow up
+// label cardinality.
+func instrument(m *metrics, mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w, status: 200}
+		next.ServeHTTP(rw, r)
+		elapsed := time.Since(start).Seconds()
+
+		route := "unmatched"
+		if _, pattern := mux.Handler(r); pattern != "" {
+			route = pattern
+		}
+		method := r.Method
+		if !knownMethods[method] {
+			method = "other"
+		}
+		status := strconv.Itoa(rw.status)
+		m.requests.inc(route, method, status)
+		m.latency.observe(elapsed, route, status)
+		for _, f := range detectFlags(r.Method, r.URL.Path, r.UserAgent(), rw.status) {
+			m.flagged.inc(f)
+		}
+	})
+}
+
+// serveHTTP writes all metrics in the Prometheus text exposition format.
+func (m *metrics) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	m.requests.write(w)
+	m.latency.write(w)
+	m.flagged.write(w)
+	writeSample(w, "http_rate_limited_total", "counter", "Requests rejected by the per-IP rate limiter.", float64(m.rateLimited()))
+	m.runs.write(w)
+	m.runDuration.write(w)
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	writeSample(w, "process_start_time_seconds", "gauge", "Start time of the process since unix epoch in seconds.", float64(m.start.UnixNano())/1e9)
+	writeSample(w, "process_uptime_seconds", "gauge", "Seconds since the process started.", time.Since(m.start).Seconds())
+	writeSample(w, "go_goroutines", "gauge", "Number of goroutines that currently exist.", float64(runtime.NumGoroutine()))
+	writeSample(w, "go_memstats_heap_alloc_bytes", "gauge", "Bytes of allocated heap objects.", float64(ms.HeapAlloc))
+	writeSample(w, "go_memstats_heap_inuse_bytes", "gauge", "Bytes in in-use heap spans.", float64(ms.HeapInuse))
+	writeSample(w, "go_memstats_sys_bytes", "gauge", "Bytes of memory obtained from the OS.", float64(ms.Sys))
+	writeSample(w, "go_gc_cycles_total", "counter", "Completed GC cycles.", float64(ms.NumGC))
+	writeSample(w, "go_gc_pause_seconds_total", "counter", "Cumulative GC stop-the-world pause time.", float64(ms.PauseTotalNs)/1e9)
+}
+
+func writeSample(w io.Writer, name, typ, help string, v float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", name, help, name, typ, name, formatFloat(v))
+}
+
+// counterVec is a counter partitioned by a fixed set of labels.
+type counterVec struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values []string
+	n      float64
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{name: name, help: help, labels: labels, series: make(map[string]*counterSeries)}
+}
+
+func (c *counterVec) inc(values ...string) {
+	key := strings.Join(values, "\xff")
+	c.mu.Lock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &counterSeries{values: values}
+		c.series[key] = s
+	}
+	s.n++
+	c.mu.Unlock()
+}
+
+func (c *counterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, labelString(c.labels, s.values, "", ""), formatFloat(s.n))
+	}
+}
+
+// histogramVec is a cumulative histogram partitioned by a fixed set of labels.
+type histogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values []string
+	counts []uint64 // per bucket, non-cumulative
+	sum    float64
+	count  uint64
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	return &histogramVec{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*histogramSeries)}
+}
+
+func (h *histogramVec) observe(v float64, values ...string) {
+	key := strings.Join(values, "\xff")
+	h.mu.Lock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{values: values, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.sum += v
+	s.count++
+	h.mu.Unlock()
+}
+
+func (h *histogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cum uint64
+		for i, le := range h.buckets {
+			cum += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelString(h.labels, s.values, "le", formatFloat(le)), cum)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelString(h.labels, s.values, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, labelString(h.labels, s.values, "", ""), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, labelString(h.labels, s.values, "", ""), s.count)
+	}
+}
+
+// labelString renders {k="v",...}, optionally appending one extra label.
+func labelString(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, n := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%q", n, escapeLabel(values[i]))
+	}
+	if extraName != "" {
+		if len(names) > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%q", extraName, extraValue)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// escapeLabel strips characters that %q would escape differently from the
+// exposition format; label values here are routes, methods and codes.
+func escapeLabel(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
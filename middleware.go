@@ -1,8 +1,7 @@
 package main
 
 import (
-	"encoding/json"
-	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
@@ -70,9 +69,11 @@ func clientIPMiddleware(r *http.Request) string {
 	return addr
 }
 
-// accessLog is an HTTP middleware that logs every request as structured JSON.
-// It detects scanner patterns and flags suspicious requests for easy monitoring.
-func accessLog(next http.Handler) http.Handler {
+// accessLog is an HTTP middleware that logs every request through slog.
+// It detects scanner patterns and flags suspicious requests (logged at warn
+// level) for easy monitoring. Detection always sees the raw request; priv
+// decides what is actually written.
+func accessLog(priv *logPrivacy, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		rw := &responseWriter{ResponseWriter: w, status: 200}
@@ -86,25 +87,29 @@ func accessLog(next http.Handler) http.Handler {
 
 		flags := detectFlags(method, path, ua, rw.status)
 
-		entry := map[string]interface{}{
-			"ts":         time.Now().UTC().Format(time.RFC3339),
-			"method":     method,
-			"path":       path,
-			"status":     rw.status,
-			"latency_ms": latency.Milliseconds(),
-			"ip":         ip,
-			"ua":         ua,
-			"size":       rw.size,
+		attrs := []slog.Attr{
+			slog.String("method", method),
+			slog.String("path", path),
+			slog.Int("status", rw.status),
+			slog.Int64("latency_ms", latency.Milliseconds()),
+			slog.Int("size", rw.size),
 		}
+		if v := priv.ip(ip); v != "" {
+			attrs = append(attrs, slog.String("ip", v))
+		}
+		if v := priv.userAgent(ua); v != "" {
+			attrs = append(attrs, slog.String("ua", v))
+		}
+		level := slog.LevelInfo
 		if len(flags) > 0 {
-			entry["flags"] = flags
+			attrs = append(attrs, slog.Any("flags", flags))
+			level = slog.LevelWarn
 		}
-		if ref := r.Referer(); ref != "" {
-			entry["referer"] = ref
+		if ref := priv.referer(r.Referer()); ref != "" {
+			attrs = append(attrs, slog.String("referer", ref))
 		}
 
-		b, _ := json.Marshal(entry)
-		fmt.Println(string(b))
+		slog.LogAttrs(r.Context(), level, "request", attrs...)
 	})
 }
 
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+// categoryGroup is one category and its concepts in number order.
+type categoryGroup struct {
+	Name     string
+	Concepts []Concept
+}
+
+// categories groups concepts by category, ordering categories by their lowest
+// concept number exactly as the SPA's unlearned list does.
+func (idx *conceptIndex) categories() []categoryGroup {
+	var groups []categoryGroup
+	pos := make(map[string]int)
+	for _, c := range idx.list { // already sorted by number
+		i, ok := pos[c.Category]
+		if !ok {
+			i = len(groups)
+			pos[c.Category] = i
+			groups = append(groups, categoryGroup{Name: c.Category})
+		}
+		groups[i].Concepts = append(groups[i].Concepts, c)
+	}
+	return groups
+}
+
+// pages serves the server-rendered, JavaScript-free views of the catalogue so
+// concepts can be linked to, indexed and read offline.
+type pages struct {
+	site    *assets
+	idx     *conceptIndex
+	baseURL string // absolute site URL for the sitemap, which is off without one
+}
+
+// conceptLink is a prerequisite or related topic. Concept is nil when the ID
+// does not resolve, in which case the raw ID is shown unlinked.
+type conceptLink struct {
+	ID      string
+	Concept *Concept
+}
+
+func (p *pages) links(ids []string) []conceptLink {
+	out := make([]conceptLink, len(ids))
+	for i, id := range ids {
+		out[i] = conceptLink{ID: id}
+		if j, ok := p.idx.byID[id]; ok {
+			out[i].Concept = &p.idx.list[j]
+		}
+	}
+	return out
+}
+
+// conceptPage serves GET /concepts/{id}.
+func (p *pages) conceptPage(w http.ResponseWriter, r *http.Request) {
+	i, ok := p.idx.byID[r.PathValue("id")]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		p.site.render(w, r, "notfound.html", nil)
+		return
+	}
+	data := struct {
+		Concept       Concept
+		Prerequisites []conceptLink
+		Related       []conceptLink
+		Prev, Next    *Concept
+	}{
+		Concept:       p.idx.list[i],
+		Prerequisites: p.links(p.idx.list[i].Prerequisites),
+		Related:       p.links(p.idx.list[i].RelatedTopics),
+	}
+	if i > 0 {
+		data.Prev = &p.idx.list[i-1]
+	}
+	if i+1 < len(p.idx.list) {
+		data.Next = &p.idx.list[i+1]
+	}
+	p.site.render(w, r, "concept.html", data)
+}
+
+// indexPage serves GET /concepts/, the category index.
+func (p *pages) indexPage(w http.ResponseWriter, r *http.Request) {
+	p.site.render(w, r, "concepts.html", p.idx.categories())
+}
+
+type sitemapURL struct {
+	Loc string `xml:"loc"`
+}
+
+// sitemap serves GET /sitemap.xml listing the SPA, the index and every
+// concept page. Sitemap URLs must be absolute, and the Host header is the
+// client's to choose, so it needs -base-url.
+func (p *pages) sitemap(w http.ResponseWriter, r *http.Request) {
+	base := p.baseURL
+	if base == "" {
+		http.NotFound(w, r)
+		return
+	}
+	urls := []sitemapURL{{Loc: base + "/"}, {Loc: base + "/concepts/"}}
+	for _, c := range p.idx.list {
+		urls = append(urls, sitemapURL{Loc: base + "/concepts/" + c.ID})
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name     `xml:"urlset"`
+		XMLNS   string       `xml:"xmlns,attr"`
+		URLs    []sitemapURL `xml:"url"`
+	}{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9", URLs: urls})
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// logPrivacy controls how much identifying data accessLog persists. The zero
+// value logs everything verbatim, matching the historical behaviour.
+type logPrivacy struct {
+	ipMode      string // full, truncate, hash or none
+	uaMode      string // full, reduced or none
+	refererMode string // full, strip (drop query and fragment) or none
+
+	// Keyed hashing: the HMAC key for an IP is derived from secret and the
+	// current rotation period, so hashes are only linkable within a period.
+	secret   []byte
+	rotation time.Duration
+	now      func() time.Time
+
+	// learnerKey keys learner pseudonyms. It never rotates, so a learner's
+	// runs stay linkable across days and restarts; nil disables them.
+	learnerKey []byte
+}
+
+// newLogPrivacy validates the modes. An empty secret is replaced with a random
+// per-process one, which also rotates all keys on restart.
+func newLogPrivacy(ipMode, uaMode, refererMode, secret string, rotation time.Duration) (*logPrivacy, error) {
+	switch ipMode {
+	case "full", "truncate", "hash", "none":
+	default:
+		return nil, fmt.Errorf("invalid IP log mode %q (want full, truncate, hash or none)", ipMode)
+	}
+	switch uaMode {
+	case "full", "reduced", "none":
+	default:
+		return nil, fmt.Errorf("invalid user agent log mode %q (want full, reduced or none)", uaMode)
+	}
+	switch refererMode {
+	case "full", "strip", "none":
+	default:
+		return nil, fmt.Errorf("invalid referer log mode %q (want full, strip or none)", refererMode)
+	}
+	if rotation <= 0 {
+		return nil, fmt.Errorf("IP hash key rotation must be positive, got %s", rotation)
+	}
+
+	p := &logPrivacy{ipMode: ipMode, uaMode: uaMode, refererMode: refererMode, rotation: rotation, now: time.Now}
+	if secret != "" {
+		p.secret = []byte(secret)
+	} else {
+		p.secret = randomBytes(32)
+	}
+	return p, nil
+}
+
+// ip returns the client IP as it should appear in the log, or "" to omit it.
+func (p *logPrivacy) ip(ip string) string {
+	switch p.ipMode {
+	case "truncate":
+		return truncateIP(ip)
+	case "hash":
+		return p.hashIP(ip)
+	case "none":
+		return ""
+	}
+	return ip
+}
+
+// truncateIP zeroes the host part: IPv4 to /24 and IPv6 to /48.
+func truncateIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// learner returns a pseudonym for a learner ID, which never matches the ID
+// itself, or false when no learner key is configured.
+func (p *logPrivacy) learner(id string) (string, bool) {
+	if p.learnerKey == nil {
+		return "", false
+	}
+	mac := hmac.New(sha256.New, p.learnerKey)
+	mac.Write([]byte("learner:" + id))
+	return hex.EncodeToString(mac.Sum(nil)[:8]), true
+}
+
+// loadLearnerKey returns the learner pseudonym key: secret if set, otherwise
+// the key stored at path, created on first use. With neither it returns nil.
+func loadLearnerKey(secret, path string) ([]byte, error) {
+	if secret != "" {
+		return []byte(secret), nil
+	}
+	if path == "" {
+		return nil, nil
+	}
+	key, err := os.ReadFile(path)
+	if err == nil && len(key) > 0 {
+		return key, nil
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	key = []byte(hex.EncodeToString(randomBytes(32)))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, key, 0o600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+func (p *logPrivacy) hashIP(ip string) string {
+	period := uint64(p.now().UnixNano() / int64(p.rotation))
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], period)
+	kdf := hmac.New(sha256.New, p.secret)
+	kdf.Write(buf[:])
+	key := kdf.Sum(nil)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// userAgent returns the user agent as it should appear in the log.
+func (p *logPrivacy) userAgent(ua string) string {
+	switch p.uaMode {
+	case "reduced":
+		return reduceUA(ua)
+	case "none":
+		return ""
+	}
+	return ua
+}
+
+var uaProducts = []struct {
+	token, name string
+}{
+	// Order matters: Edge and Opera also advertise Chrome, Chrome advertises Safari.
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Firefox/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"Version/", "Safari"},
+	{"curl/", "curl"},
+}
+
+var uaOSes = []struct {
+	token, name string
+}{
+	{"Android", "Android"},
+	{"iPhone", "iOS"},
+	{"iPad", "iOS"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"CrOS", "ChromeOS"},
+	{"Linux", "Linux"},
+}
+
+var uaMajor = regexp.MustCompile(`^\d+`)
+
+// reduceUA collapses a user agent to browser family, major version and OS
+// family, e.g. "Chrome/120 (Linux)", which is enough for compatibility
+// statistics without the fingerprinting detail.
+func reduceUA(ua string) string {
+	if ua == "" {
+		return ""
+	}
+	product := "other"
+	for _, p := range uaProducts {
+		if i := strings.Index(ua, p.token); i >= 0 {
+			product = p.name
+			if v := uaMajor.FindString(ua[i+len(p.token):]); v != "" {
+				product += "/" + v
+			}
+			break
+		}
+	}
+	for _, o := range uaOSes {
+		if strings.Contains(ua, o.token) {
+			return product + " (" + o.name + ")"
+		}
+	}
+	return product
+}
+
+// referer returns the referer as it should appear in the log.
+func (p *logPrivacy) referer(ref string) string {
+	switch p.refererMode {
+	case "strip":
+		u, err := url.Parse(ref)
+		if err != nil {
+			return ""
+		}
+		u.RawQuery = ""
+		u.Fragment = ""
+		u.User = nil
+		return u.String()
+	case "none":
+		return ""
+	}
+	return ref
+}
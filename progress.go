@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go-concept-trainer/concepts"
+	"go-concept-trainer/integrity"
+	"go-concept-trainer/progress"
+)
+
+// maxCachedProgress bounds how many learners' files a progressStore with a
+// directory keeps in memory.
+const maxCachedProgress = 1024
+
+// progressStore keeps each learner's imported progress. With a directory it
+// persists one file per learner and caches up to maxCachedProgress of them;
+// otherwise it lives in memory only, for every learner, until the process
+// exits.
+type progressStore struct {
+	dir string
+
+	mu    sync.Mutex
+	files map[string]*progress.File
+}
+
+func newProgressStore(dir string) (*progressStore, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, err
+		}
+	}
+	return &progressStore{dir: dir, files: make(map[string]*progress.File)}, nil
+}
+
+func (s *progressStore) get(learner string) (*progress.File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(learner)
+}
+
+// update applies fn to a copy of the learner's progress, starting from an
+// empty file if there is none yet, and saves the result. Readers holding the
+// previous file are unaffected. fn's error aborts the update.
+func (s *progressStore) update(learner string, fn func(*progress.File) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.load(learner)
+	if errors.Is(err, os.ErrNotExist) {
+		f, err = progress.New(), nil
+	}
+	if err != nil {
+		return err
+	}
+	next := *f
+	next.Concepts = maps.Clone(f.Concepts)
+	if err := fn(&next); err != nil {
+		return err
+	}
+	return s.save(learner, &next)
+}
+
+func (s *progressStore) load(learner string) (*progress.File, error) {
+	if f, ok := s.files[learner]; ok {
+		return f, nil
+	}
+	if s.dir == "" {
+		return nil, os.ErrNotExist
+	}
+	fh, err := os.Open(filepath.Join(s.dir, learner+".json"))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	f, err := progress.Parse(fh)
+	if err != nil {
+		return nil, err
+	}
+	s.cache(learner, f)
+	return f, nil
+}
+
+// cache remembers f for the learner. When files are on disk a full cache is
+// emptied, to be read back as learners return; in memory it is the only
+// copy.
+func (s *progressStore) cache(learner string, f *progress.File) {
+	if s.dir != "" && len(s.files) >= maxCachedProgress {
+		clear(s.files)
+	}
+	s.files[learner] = f
+}
+
+func (s *progressStore) save(learner string, f *progress.File) error {
+	if s.dir != "" {
+		var buf bytes.Buffer
+		if err := f.Write(&buf); err != nil {
+			return err
+		}
+		tmp := filepath.Join(s.dir, learner+".json.tmp")
+		if err := os.WriteFile(tmp, buf.Bytes(), 0o640); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, filepath.Join(s.dir, learner+".json")); err != nil {
+			return err
+		}
+	}
+	s.cache(learner, f)
+	return nil
+}
+
+// progressAPI serves progress import and export.
+type progressAPI struct {
+	idx      *conceptIndex
+	store    *progressStore
+	grader   *grader
+	exams    *examStore
+	sessions *sessionStore
+	replays  *replayStore
+}
+
+func (p *progressAPI) known(id string) bool {
+	_, ok := p.idx.byID[id]
+	return ok
+}
+
+// storedLearner returns the caller's learner ID if the server already holds
+// progress for it. Endpoints a new browser reaches before it has any use it
+// rather than ensureLearner, so requests without a cookie store nothing.
+func (p *progressAPI) storedLearner(r *http.Request) (string, bool, error) {
+	learner, ok := learnerID(r)
+	if !ok {
+		return "", false, nil
+	}
+	if _, err := p.store.get(learner); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return "", false, err
+	}
+	return learner, true, nil
+}
+
+// importProgress serves POST /api/progress/import. The body is a progress
+// file of any supported version. It is migrated, validated against the
+// current concept IDs, stored as the learner's server-side copy and echoed
+// back in the current format so the browser can load it. Unknown concept
+// IDs are rejected unless ?drop_unknown=true, in which case they are removed
+// and listed in the X-Dropped-Concepts header.
+func (p *progressAPI) importProgress(w http.ResponseWriter, r *http.Request) {
+	f, err := progress.Parse(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if r.URL.Query().Get("drop_unknown") == "true" {
+		if dropped := f.Drop(p.known); len(dropped) > 0 {
+			w.Header().Set("X-Dropped-Concepts", joinIDs(dropped))
+		}
+	}
+	if err := f.Validate(p.known); err != nil {
+		var verr *progress.ValidationError
+		if errors.As(err, &verr) {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": "invalid progress file", "problems": verr.Problems})
+			return
+		}
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	learner := ensureLearner(w, r)
+	err = p.store.update(learner, func(cur *progress.File) error {
+		// Hints revealed, quiz answers missed, the variant picked and
+		// the attempt history here stay; files from the browser do not
+		// carry them.
+		for id, rec := range cur.Concepts {
+			if imported, ok := f.Concepts[id]; ok {
+				imported.HintLevel = max(imported.HintLevel, rec.HintLevel)
+				imported.QuizMisses = max(imported.QuizMisses, rec.QuizMisses)
+				if imported.Seed == 0 {
+					imported.Seed = rec.Seed
+				}
+				if len(imported.History) == 0 {
+					imported.History = rec.History
+				}
+				f.Concepts[id] = imported
+			}
+		}
+		*cur = *f
+		return nil
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "could not store progress")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	f.Write(w)
+}
+
+// exportProgress serves GET /api/progress/export: the learner's server-side
+// copy as a downloadable current-version file.
+func (p *progressAPI) exportProgress(w http.ResponseWriter, r *http.Request) {
+	learner, ok := learnerID(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "no stored progress for this browser")
+		return
+	}
+	f, err := p.store.get(learner)
+	if errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusNotFound, "no stored progress for this browser")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "could not read stored progress")
+		return
+	}
+	out := *f
+	out.ExportedAt = time.Now().UTC()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="clanker-rehab-progress.json"`)
+	w.Header().Set("Cache-Control", "no-store")
+	out.Write(w)
+}
+
+func joinIDs(ids []string) string {
+	return strings.Join(ids, ",")
+}
+
+// learnedRequest is the body of POST /api/progress/{id}/learned.
+type learnedRequest struct {
+	ExpiryDays int  `json:"expiryDays"` // the learner's default interval
+	Assisted   bool `json:"assisted"`   // opened the explanation panel
+	Tries      int  `json:"tries"`      // runs or checks up to the pass; 0 if unknown
+	// The solution that passed: code, or for predict concepts the
+	// predicted output.
+	Code   string `json:"code"`
+	Output string `json:"output"`
+	// Edits is the editor's log of the attempt; predict concepts have none.
+	// Without it, or when it does not replay to Code, a code solution
+	// counts as pasted.
+	Edits *integrity.Log `json:"edits"`
+}
+
+var errSeedChanged = errors.New("the exercise changed since it was graded")
+
+// markLearned serves POST /api/progress/{id}/learned. It grades the
+// solution again, records the concept as learned in the learner's
+// server-side copy, schedules the review from the hints revealed and quiz
+// answers missed during the attempt, and starts the next attempt afresh,
+// with a new variant of a parameterised concept. Help outside the hint
+// ladder counts as the first hint, and so does a solution that was largely
+// pasted rather than typed, judged from the editor's log. A code solution
+// sent without a log, or with one that does not replay to the code, counts
+// as pasted, since nothing shows it was typed. Without a sandbox-runner the
+// solution cannot be graded here and the browser's word is taken for it.
+// The attempt is added to the concept's history for the mastery model, and
+// the log is kept for /api/replay. The response is the learned record for
+// the browser to keep.
+func (p *progressAPI) markLearned(w http.ResponseWriter, r *http.Request) {
+	i, ok := p.idx.byID[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "concept not found")
+		return
+	}
+	c := &p.idx.list[i]
+	var req learnedRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 2<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.ExpiryDays < 1 || req.ExpiryDays > 365 {
+		writeError(w, http.StatusBadRequest, "expiryDays must be between 1 and 365")
+		return
+	}
+	if req.Tries < 0 {
+		writeError(w, http.StatusBadRequest, "tries must not be negative")
+		return
+	}
+	if req.Edits != nil {
+		if err := req.Edits.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	learner := ensureLearner(w, r)
+	var seed uint64
+	if f, err := p.store.get(learner); err == nil {
+		seed = f.Concepts[c.ID].Seed
+	}
+	res, err := p.grader.check(r.Context(), c, gradeRequest{Code: req.Code, Output: req.Output, Seed: seed})
+	switch {
+	case errors.Is(err, errNoRunner):
+		// Nothing here can run it; the browser already did.
+	case err != nil:
+		p.grader.runFailed(w, r, err)
+		return
+	case !res.Correct:
+		writeError(w, http.StatusUnprocessableEntity, "the solution does not pass")
+		return
+	}
+	if req.Edits != nil && c.Kind != concepts.KindPredict {
+		if text, err := req.Edits.Replay(); err != nil || text != req.Code {
+			req.Edits = nil
+		}
+	}
+
+	levels := len(c.Hints)
+	var learned progress.Learned
+	var signals *integrity.Signals
+	err = p.store.update(learner, func(f *progress.File) error {
+		rec := f.Concepts[c.ID]
+		if rec.Seed != seed {
+			return errSeedChanged
+		}
+		level := rec.HintLevel
+		if req.Edits != nil {
+			s := integrity.Analyze(*req.Edits, instantiate(c, rec.Seed).Boilerplate)
+			signals = &s
+		}
+		pasted := c.Kind != concepts.KindPredict && (signals == nil || signals.LargePaste)
+		if req.Assisted || pasted {
+			level = max(level, 1)
+		}
+		now := time.Now().UTC()
+		rec.AddAttempt(progress.Attempt{
+			At:         now,
+			Tries:      req.Tries,
+			HintLevel:  level,
+			QuizMisses: rec.QuizMisses,
+			Assisted:   level > 0,
+			Review:     rec.Learned != nil,
+			Overdue:    rec.Learned != nil && !now.Before(rec.Learned.DueAt()),
+			Integrity:  signals,
+		})
+		learned = progress.Learned{
+			LearnedAt:  now,
+			ExpiryDays: progress.QuizReviewDays(progress.ReviewDays(req.ExpiryDays, level, levels), rec.QuizMisses, len(c.Questions)),
+			Assisted:   level > 0,
+			HintLevel:  level,
+			QuizMisses: rec.QuizMisses,
+			Version:    c.Version,
+			Pasted:     pasted,
+		}
+		rec.Learned = &learned
+		rec.HintLevel, rec.QuizMisses, rec.Seed = 0, 0, 0
+		f.Concepts[c.ID] = rec
+		return nil
+	})
+	if errors.Is(err, errSeedChanged) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "could not store progress")
+		return
+	}
+	if signals != nil {
+		if err := p.replays.save(learner, c.ID, &replay{At: learned.LearnedAt, Signals: *signals, Log: *req.Edits}); err != nil {
+			slog.WarnContext(r.Context(), "could not store replay", "concept", c.ID, "err", err)
+		}
+	}
+	writeJSON(w, http.StatusOK, learned)
+}
@@ -0,0 +1,78 @@
+package progress
+
+import "slices"
+
+// Merge combines two progress files into a new one without modifying either.
+//
+// For each concept the most recent learned record wins, and the solution
+// travels with it: the solution that was submitted when the concept was
+// last learned is the one that passed. A side that has a solution but no
+// learned record only contributes it when the other side has none. Drafts
+// and settings prefer the file exported most recently, falling back to
+// whichever side has one, as do variant seeds. Hint levels and quiz misses
+// keep the larger of the two. Histories are combined, dropping attempts
+// both sides have.
+func Merge(a, b *File) *File {
+	newer, older := b, a
+	if a.ExportedAt.After(b.ExportedAt) {
+		newer, older = a, b
+	}
+
+	out := New()
+	out.Settings = newer.Settings
+	if out.Settings == nil {
+		out.Settings = older.Settings
+	}
+
+	for _, f := range []*File{older, newer} {
+		for id := range f.Concepts {
+			if _, done := out.Concepts[id]; done {
+				continue
+			}
+			out.Concepts[id] = mergeRecord(older.Concepts[id], newer.Concepts[id])
+		}
+	}
+	return out
+}
+
+func mergeRecord(older, newer Record) Record {
+	var out Record
+	winner, loser := newer, older
+	switch {
+	case older.Learned != nil && newer.Learned == nil:
+		winner, loser = older, newer
+	case older.Learned != nil && newer.Learned != nil && older.Learned.LearnedAt.After(newer.Learned.LearnedAt):
+		winner, loser = older, newer
+	}
+	if winner.Learned != nil {
+		l := *winner.Learned
+		out.Learned = &l
+	}
+	out.Solution = winner.Solution
+	if out.Solution == "" {
+		out.Solution = loser.Solution
+	}
+
+	out.Draft = newer.Draft
+	if out.Draft == "" {
+		out.Draft = older.Draft
+	}
+	out.HintLevel = max(older.HintLevel, newer.HintLevel)
+	out.QuizMisses = max(older.QuizMisses, newer.QuizMisses)
+	out.Seed = newer.Seed
+	if out.Seed == 0 {
+		out.Seed = older.Seed
+	}
+	out.History = mergeHistory(older.Attempts(), newer.Attempts())
+	return out
+}
+
+func mergeHistory(a, b []Attempt) []Attempt {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	all := slices.Concat(a, b)
+	slices.SortStableFunc(all, func(x, y Attempt) int { return x.At.Compare(y.At) })
+	all = slices.CompactFunc(all, func(x, y Attempt) bool { return x.At.Equal(y.At) })
+	return all[max(0, len(all)-MaxHistory):]
+}
@@ -0,0 +1,349 @@
+// Package progress defines the portable progress file learners use to move
+// their state between browsers, and the validation, migration and merge
+// rules for it.
+//
+// A version 2 file looks like:
+//
+//	{
+//	  "format": "clanker-rehab-progress",
+//	  "version": 2,
+//	  "exportedAt": "2026-10-19T08:00:00Z",
+//	  "settings": {"defaultExpiryDays": 14, "sessionMinutes": 20},
+//	  "concepts": {
+//	    "mutex": {
+//	      "learned": {"learnedAt": "2026-10-01T09:30:00Z", "expiryDays": 14, "assisted": false, "hintLevel": 0, "version": "3ad74f7551f1"},
+//	      "solution": "package main\n...",
+//	      "draft": "package main\n...",
+//	      "hintLevel": 2,
+//	      "quizMisses": 1,
+//	      "seed": 3141592653,
+//	      "history": [
+//	        {"at": "2026-10-01T09:30:00Z", "tries": 3, "hintLevel": 1,
+//	         "integrity": {"typed": 412, "pasted": 0, "deleted": 37, "pasteRatio": 0, "durationMs": 254000}}
+//	      ]
+//	    }
+//	  }
+//	}
+//
+// Every field of a concept entry is optional. Timestamps are RFC 3339.
+// hintLevel on the entry counts the hints revealed in the current attempt;
+// the one inside learned is how many were used when it was learned.
+// quizMisses likewise counts quiz questions answered wrongly. seed picks the
+// current attempt's variant of a parameterised concept. history lists the
+// times the concept was learned, oldest first, for the mastery model, with
+// how the solution was typed (see package integrity).
+// Version 0 is the raw localStorage dump the browser app kept before this
+// format existed (learnedConcepts, solutions, drafts and settings as
+// top-level keys, with learnedAt in Unix milliseconds); Parse migrates it.
+// Version 1 had only learned, solution and draft on a concept entry, and
+// hintLevel on learned; Parse reads it as version 2.
+//
+// Parse rejects fields it does not know, so a file from a newer build fails
+// with an unsupported-version error rather than losing data: any new field
+// needs a new version.
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go-concept-trainer/integrity"
+)
+
+const (
+	// FormatName identifies progress files.
+	FormatName = "clanker-rehab-progress"
+	// CurrentVersion is the version Parse migrates to and Write emits.
+	CurrentVersion = 2
+
+	maxExpiryDays = 365
+	// MaxHistory is how many attempts a record keeps.
+	MaxHistory = 20
+
+	// MinSessionMinutes and MaxSessionMinutes bound the daily session's
+	// time budget.
+	MinSessionMinutes = 5
+	MaxSessionMinutes = 240
+)
+
+// File is a learner's complete progress.
+type File struct {
+	Format     string            `json:"format"`
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exportedAt"`
+	Settings   *Settings         `json:"settings,omitempty"`
+	Concepts   map[string]Record `json:"concepts"`
+}
+
+// Settings are the learner's preferences.
+type Settings struct {
+	DefaultExpiryDays int `json:"defaultExpiryDays"`
+	// SessionMinutes is the time budget of the daily session; 0 means the
+	// server's default.
+	SessionMinutes int `json:"sessionMinutes,omitempty"`
+}
+
+// Record is everything stored about one concept.
+type Record struct {
+	Learned  *Learned `json:"learned,omitempty"`
+	Solution string   `json:"solution,omitempty"`
+	Draft    string   `json:"draft,omitempty"`
+	// HintLevel is the highest hint revealed since the concept was last
+	// learned; 0 means none.
+	HintLevel int `json:"hintLevel,omitempty"`
+	// QuizMisses counts quiz answers that were wrong since the concept was
+	// last learned.
+	QuizMisses int `json:"quizMisses,omitempty"`
+	// Seed picks the current attempt's variant of a parameterised
+	// concept; 0 means none has been picked yet.
+	Seed uint64 `json:"seed,omitempty"`
+	// History holds the latest MaxHistory attempts, oldest first.
+	History []Attempt `json:"history,omitempty"`
+}
+
+// Attempt is one time a concept was learned: how much it took to pass.
+type Attempt struct {
+	At         time.Time `json:"at"`
+	Tries      int       `json:"tries,omitempty"` // runs or checks up to the pass; 0 if unknown
+	HintLevel  int       `json:"hintLevel,omitempty"`
+	QuizMisses int       `json:"quizMisses,omitempty"`
+	Assisted   bool      `json:"assisted,omitempty"`
+	Review     bool      `json:"review,omitempty"`  // the concept had been learned before
+	Overdue    bool      `json:"overdue,omitempty"` // and its review was due
+	// Integrity is how the solution was typed, when the editor's log
+	// came with it.
+	Integrity *integrity.Signals `json:"integrity,omitempty"`
+}
+
+// Clean reports whether the attempt passed first time without help.
+func (a Attempt) Clean() bool {
+	return a.Tries <= 1 && a.HintLevel == 0 && a.QuizMisses == 0 && !a.Assisted
+}
+
+// Attempts returns the history, or for a concept learned before histories
+// were kept, one attempt made up from its learned record.
+func (r Record) Attempts() []Attempt {
+	if len(r.History) > 0 || r.Learned == nil {
+		return r.History
+	}
+	l := r.Learned
+	return []Attempt{{At: l.LearnedAt, HintLevel: l.HintLevel, QuizMisses: l.QuizMisses, Assisted: l.Assisted}}
+}
+
+// AddAttempt appends a to the attempts, dropping the oldest beyond
+// MaxHistory.
+func (r *Record) AddAttempt(a Attempt) {
+	r.History = append(r.Attempts(), a)
+	if n := len(r.History) - MaxHistory; n > 0 {
+		r.History = slices.Clone(r.History[n:])
+	}
+}
+
+// Learned records when and how a concept was learned.
+type Learned struct {
+	LearnedAt  time.Time `json:"learnedAt"`
+	ExpiryDays int       `json:"expiryDays"`
+	Assisted   bool      `json:"assisted"`
+	HintLevel  int       `json:"hintLevel,omitempty"`  // hints used; see ReviewDays
+	QuizMisses int       `json:"quizMisses,omitempty"` // quiz answers missed; see QuizReviewDays
+	Version    string    `json:"version,omitempty"`    // concept content version it was earned against
+	Pasted     bool      `json:"pasted,omitempty"`     // largely pasted, or sent without the editor's log; makes it assisted
+}
+
+// New returns an empty current-version file.
+func New() *File {
+	return &File{Format: FormatName, Version: CurrentVersion, Concepts: make(map[string]Record)}
+}
+
+// Parse reads a progress file of any known version and migrates it to
+// CurrentVersion. It checks structure only; call Validate for the rules.
+func Parse(r io.Reader) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var probe struct {
+		Format  string `json:"format"`
+		Version *int   `json:"version"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("progress file is not valid JSON: %w", err)
+	}
+
+	switch {
+	case probe.Format == "" && probe.Version == nil:
+		return migrateV0(data)
+	case probe.Format != FormatName:
+		return nil, fmt.Errorf("unrecognised format %q", probe.Format)
+	case probe.Version == nil:
+		return nil, errors.New("missing version")
+	case *probe.Version == 1, *probe.Version == CurrentVersion:
+		// Version 2 only added fields, so a version 1 file decodes as is.
+		f := New()
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(f); err != nil {
+			return nil, fmt.Errorf("decoding version %d: %w", *probe.Version, err)
+		}
+		f.Version = CurrentVersion
+		if f.Concepts == nil {
+			f.Concepts = make(map[string]Record)
+		}
+		return f, nil
+	default:
+		return nil, fmt.Errorf("unsupported version %d (this build reads up to %d)", *probe.Version, CurrentVersion)
+	}
+}
+
+// v0 mirrors the browser's localStorage keys.
+type v0 struct {
+	LearnedConcepts map[string]struct {
+		LearnedAt  int64  `json:"learnedAt"` // Unix milliseconds
+		ExpiryDays int    `json:"expiryDays"`
+		Assisted   bool   `json:"assisted"`
+		HintLevel  int    `json:"hintLevel"`
+		Version    string `json:"version"`
+	} `json:"learnedConcepts"`
+	Solutions map[string]string `json:"solutions"`
+	Drafts    map[string]string `json:"drafts"`
+	Settings  *Settings         `json:"settings"`
+}
+
+func migrateV0(data []byte) (*File, error) {
+	var old v0
+	if err := json.Unmarshal(data, &old); err != nil {
+		return nil, fmt.Errorf("decoding legacy progress: %w", err)
+	}
+	if old.LearnedConcepts == nil && old.Solutions == nil && old.Drafts == nil && old.Settings == nil {
+		return nil, errors.New("not a progress file: no format, version or localStorage keys")
+	}
+	f := New()
+	f.Settings = old.Settings
+	for id, l := range old.LearnedConcepts {
+		rec := f.Concepts[id]
+		rec.Learned = &Learned{
+			LearnedAt:  time.UnixMilli(l.LearnedAt).UTC(),
+			ExpiryDays: l.ExpiryDays,
+			Assisted:   l.Assisted,
+			HintLevel:  l.HintLevel,
+			Version:    l.Version,
+		}
+		f.Concepts[id] = rec
+	}
+	for id, code := range old.Solutions {
+		rec := f.Concepts[id]
+		rec.Solution = code
+		f.Concepts[id] = rec
+	}
+	for id, code := range old.Drafts {
+		rec := f.Concepts[id]
+		rec.Draft = code
+		f.Concepts[id] = rec
+	}
+	return f, nil
+}
+
+// ValidationError lists every problem found by Validate.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid progress file: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks the file's values. known reports whether a concept ID
+// exists; pass nil to skip that check. The returned error, if any, is a
+// *ValidationError.
+func (f *File) Validate(known func(id string) bool) error {
+	var problems []string
+	if f.Settings != nil && (f.Settings.DefaultExpiryDays < 1 || f.Settings.DefaultExpiryDays > maxExpiryDays) {
+		problems = append(problems, fmt.Sprintf("settings.defaultExpiryDays must be between 1 and %d", maxExpiryDays))
+	}
+	if f.Settings != nil && f.Settings.SessionMinutes != 0 && (f.Settings.SessionMinutes < MinSessionMinutes || f.Settings.SessionMinutes > MaxSessionMinutes) {
+		problems = append(problems, fmt.Sprintf("settings.sessionMinutes must be between %d and %d", MinSessionMinutes, MaxSessionMinutes))
+	}
+	for _, id := range f.IDs() {
+		rec := f.Concepts[id]
+		if known != nil && !known(id) {
+			problems = append(problems, fmt.Sprintf("unknown concept %q", id))
+		}
+		if rec.HintLevel < 0 {
+			problems = append(problems, fmt.Sprintf("%s: hintLevel must not be negative", id))
+		}
+		if rec.QuizMisses < 0 {
+			problems = append(problems, fmt.Sprintf("%s: quizMisses must not be negative", id))
+		}
+		for i, a := range rec.History {
+			if a.At.IsZero() {
+				problems = append(problems, fmt.Sprintf("%s: history[%d].at is missing", id, i))
+			}
+			if a.Tries < 0 || a.HintLevel < 0 || a.QuizMisses < 0 {
+				problems = append(problems, fmt.Sprintf("%s: history[%d] counts must not be negative", id, i))
+			}
+		}
+		if len(rec.History) > MaxHistory {
+			problems = append(problems, fmt.Sprintf("%s: history has more than %d attempts", id, MaxHistory))
+		}
+		if l := rec.Learned; l != nil {
+			if l.HintLevel < 0 {
+				problems = append(problems, fmt.Sprintf("%s: learned.hintLevel must not be negative", id))
+			}
+			if l.QuizMisses < 0 {
+				problems = append(problems, fmt.Sprintf("%s: learned.quizMisses must not be negative", id))
+			}
+			if l.LearnedAt.IsZero() {
+				problems = append(problems, fmt.Sprintf("%s: learnedAt is missing", id))
+			}
+			if l.ExpiryDays < 1 || l.ExpiryDays > maxExpiryDays {
+				problems = append(problems, fmt.Sprintf("%s: expiryDays must be between 1 and %d", id, maxExpiryDays))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Drop removes the concepts for which keep returns false and returns their
+// IDs in sorted order.
+func (f *File) Drop(keep func(id string) bool) []string {
+	var dropped []string
+	for _, id := range f.IDs() {
+		if !keep(id) {
+			delete(f.Concepts, id)
+			dropped = append(dropped, id)
+		}
+	}
+	return dropped
+}
+
+// IDs returns the concept IDs in sorted order.
+func (f *File) IDs() []string {
+	ids := make([]string, 0, len(f.Concepts))
+	for id := range f.Concepts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Write encodes f as an indented current-version file, stamping ExportedAt
+// if it is unset.
+func (f *File) Write(w io.Writer) error {
+	f.Format, f.Version = FormatName, CurrentVersion
+	if f.ExportedAt.IsZero() {
+		f.ExportedAt = time.Now().UTC()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(f)
+}
@@ -0,0 +1,243 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		file  string
+		check func(t *testing.T, f *File)
+	}{
+		{"v0.json", func(t *testing.T, f *File) {
+			l := f.Concepts["mutex"].Learned
+			if l == nil {
+				t.Fatal("mutex: learned record missing")
+			}
+			want := Learned{LearnedAt: time.UnixMilli(1759311000000).UTC(), ExpiryDays: 14, Assisted: true, HintLevel: 2, Version: "3ad74f7551f1"}
+			if *l != want {
+				t.Errorf("mutex learned = %+v, want %+v", *l, want)
+			}
+			if got := f.Concepts["mutex"].Solution; got != "package main\n\nfunc main() {}\n" {
+				t.Errorf("mutex solution = %q", got)
+			}
+			if got := f.Concepts["goroutines"].Draft; got != "package main\n" {
+				t.Errorf("goroutines draft = %q", got)
+			}
+			if f.Settings == nil || f.Settings.DefaultExpiryDays != 7 {
+				t.Errorf("settings = %+v, want defaultExpiryDays 7", f.Settings)
+			}
+		}},
+		{"v1.json", func(t *testing.T, f *File) {
+			l := f.Concepts["mutex"].Learned
+			if l == nil || !l.LearnedAt.Equal(date("2026-10-01T09:30:00Z")) || l.HintLevel != 1 {
+				t.Errorf("mutex learned = %+v", l)
+			}
+			if got := f.Concepts["goroutines"].Draft; got != "package main\n" {
+				t.Errorf("goroutines draft = %q", got)
+			}
+			// A version 1 record has no history; its learned record stands
+			// in for one.
+			if got := f.Concepts["mutex"].Attempts(); len(got) != 1 || !got[0].At.Equal(l.LearnedAt) || got[0].HintLevel != 1 {
+				t.Errorf("mutex attempts = %+v", got)
+			}
+		}},
+		{"v2.json", func(t *testing.T, f *File) {
+			rec := f.Concepts["mutex"]
+			if rec.HintLevel != 2 || rec.QuizMisses != 1 || rec.Seed != 3141592653 {
+				t.Errorf("mutex counters = %d, %d, %d", rec.HintLevel, rec.QuizMisses, rec.Seed)
+			}
+			if rec.Learned == nil || !rec.Learned.Pasted {
+				t.Errorf("mutex learned = %+v, want pasted", rec.Learned)
+			}
+			if len(rec.History) != 1 || rec.History[0].Tries != 3 || rec.History[0].Integrity == nil || rec.History[0].Integrity.Typed != 412 {
+				t.Errorf("mutex history = %+v", rec.History)
+			}
+			if f.Settings == nil || f.Settings.SessionMinutes != 20 {
+				t.Errorf("settings = %+v, want sessionMinutes 20", f.Settings)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile("testdata/" + tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := Parse(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if f.Format != FormatName || f.Version != CurrentVersion {
+				t.Errorf("format, version = %q, %d; want %q, %d", f.Format, f.Version, FormatName, CurrentVersion)
+			}
+			if err := f.Validate(nil); err != nil {
+				t.Errorf("Validate: %v", err)
+			}
+			tt.check(t, f)
+
+			// Writing and parsing again gives the same file.
+			var buf bytes.Buffer
+			if err := f.Write(&buf); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			again, err := Parse(&buf)
+			if err != nil {
+				t.Fatalf("Parse of written file: %v", err)
+			}
+			if !reflect.DeepEqual(again, f) {
+				t.Errorf("round trip changed the file:\n got %+v\nwant %+v", again, f)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"not JSON", `{"format":`, "not valid JSON"},
+		{"no keys", `{}`, "not a progress file"},
+		{"other format", `{"format": "something-else", "version": 1}`, "unrecognised format"},
+		{"no version", `{"format": "clanker-rehab-progress"}`, "missing version"},
+		{"newer version", `{"format": "clanker-rehab-progress", "version": 3, "concepts": {}}`, "unsupported version 3"},
+		{"unknown field", `{"format": "clanker-rehab-progress", "version": 2, "concepts": {"mutex": {"streak": 4}}}`, "unknown field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.in))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	known := func(id string) bool { return id == "mutex" }
+	f := New()
+	f.Settings = &Settings{DefaultExpiryDays: 400}
+	f.Concepts["mutex"] = Record{Learned: &Learned{ExpiryDays: 14}}
+	f.Concepts["telepathy"] = Record{HintLevel: -1}
+
+	err := f.Validate(known)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate error = %v, want a *ValidationError", err)
+	}
+	want := []string{
+		"settings.defaultExpiryDays must be between 1 and 365",
+		"mutex: learnedAt is missing",
+		`unknown concept "telepathy"`,
+		"telepathy: hintLevel must not be negative",
+	}
+	if !reflect.DeepEqual(verr.Problems, want) {
+		t.Errorf("problems = %q, want %q", verr.Problems, want)
+	}
+
+	if dropped := f.Drop(known); !reflect.DeepEqual(dropped, []string{"telepathy"}) {
+		t.Errorf("Drop = %q, want [telepathy]", dropped)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	early, late := date("2026-10-01T09:00:00Z"), date("2026-10-10T09:00:00Z")
+	learned := func(at time.Time) *Learned { return &Learned{LearnedAt: at, ExpiryDays: 14} }
+
+	tests := []struct {
+		name  string
+		older Record // from the file exported first
+		newer Record
+		want  Record
+	}{
+		{
+			name:  "later learned record wins with its solution",
+			older: Record{Learned: learned(late), Solution: "late"},
+			newer: Record{Learned: learned(early), Solution: "early"},
+			want:  Record{Learned: learned(late), Solution: "late", History: []Attempt{{At: early}, {At: late}}},
+		},
+		{
+			name:  "learned beats not learned",
+			older: Record{Learned: learned(early), Solution: "passed"},
+			newer: Record{Solution: "scratch"},
+			want:  Record{Learned: learned(early), Solution: "passed", History: []Attempt{{At: early}}},
+		},
+		{
+			name:  "solution without a learned record fills a gap",
+			older: Record{Solution: "kept"},
+			newer: Record{Learned: learned(early)},
+			want:  Record{Learned: learned(early), Solution: "kept", History: []Attempt{{At: early}}},
+		},
+		{
+			name:  "draft and seed prefer the newer file",
+			older: Record{Draft: "old", Seed: 1},
+			newer: Record{Draft: "new", Seed: 2},
+			want:  Record{Draft: "new", Seed: 2},
+		},
+		{
+			name:  "draft and seed fall back to the older file",
+			older: Record{Draft: "old", Seed: 1},
+			newer: Record{},
+			want:  Record{Draft: "old", Seed: 1},
+		},
+		{
+			name:  "counters keep the larger",
+			older: Record{HintLevel: 3, QuizMisses: 0},
+			newer: Record{HintLevel: 1, QuizMisses: 2},
+			want:  Record{HintLevel: 3, QuizMisses: 2},
+		},
+		{
+			name:  "histories combine without duplicates",
+			older: Record{Learned: learned(early), History: []Attempt{{At: early, Tries: 2}}},
+			newer: Record{Learned: learned(late), History: []Attempt{{At: early, Tries: 2}, {At: late, Tries: 1}}},
+			want:  Record{Learned: learned(late), History: []Attempt{{At: early, Tries: 2}, {At: late, Tries: 1}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := New(), New()
+			a.ExportedAt, b.ExportedAt = early, late
+			a.Concepts["mutex"], b.Concepts["mutex"] = tt.older, tt.newer
+
+			// The order of the arguments does not matter.
+			for _, got := range []*File{Merge(a, b), Merge(b, a)} {
+				if !reflect.DeepEqual(got.Concepts["mutex"], tt.want) {
+					t.Errorf("merged = %+v, want %+v", got.Concepts["mutex"], tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeFiles(t *testing.T) {
+	a, b := New(), New()
+	a.ExportedAt, b.ExportedAt = date("2026-10-01T09:00:00Z"), date("2026-10-10T09:00:00Z")
+	a.Settings = &Settings{DefaultExpiryDays: 7}
+	a.Concepts["mutex"] = Record{Draft: "a"}
+	b.Concepts["goroutines"] = Record{Draft: "b"}
+
+	got := Merge(a, b)
+	if got.Settings == nil || got.Settings.DefaultExpiryDays != 7 {
+		t.Errorf("settings = %+v, want the only ones there are", got.Settings)
+	}
+	if ids := got.IDs(); !reflect.DeepEqual(ids, []string{"goroutines", "mutex"}) {
+		t.Errorf("concepts = %q, want both sides'", ids)
+	}
+	if len(a.Concepts) != 1 || len(b.Concepts) != 1 {
+		t.Error("Merge modified its arguments")
+	}
+}
@@ -0,0 +1,51 @@
+package progress
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// minReviewFactor is how much of the interval is left after revealing
+	// the full answer.
+	minReviewFactor = 0.25
+	// minQuizFactor is how much of the interval is left after missing
+	// every quiz question.
+	minQuizFactor = 0.5
+)
+
+// ReviewDays scales a base review interval by how much help the learner
+// needed: hintLevel of hintLevels hints revealed. No hints keeps the full
+// interval, the last hint (the answer) leaves a quarter of it, and levels in
+// between scale linearly. The result is at least one day.
+func ReviewDays(baseDays, hintLevel, hintLevels int) int {
+	factor := 1.0
+	if hintLevels > 0 && hintLevel > 0 {
+		factor -= (1 - minReviewFactor) * float64(min(hintLevel, hintLevels)) / float64(hintLevels)
+	}
+	return min(maxExpiryDays, max(1, int(math.Round(float64(baseDays)*factor))))
+}
+
+// QuizReviewDays shortens an interval for quiz questions answered wrongly:
+// misses of questions. Missing all of them, or missing some repeatedly,
+// halves it; in between it scales linearly. The result is at least one day.
+func QuizReviewDays(days, misses, questions int) int {
+	factor := 1.0
+	if questions > 0 && misses > 0 {
+		factor -= (1 - minQuizFactor) * float64(min(misses, questions)) / float64(questions)
+	}
+	return max(1, int(math.Round(float64(days)*factor)))
+}
+
+// BringForward makes a learned concept due for review now, or a day after
+// it was learned if that is later, unless it is already due sooner. A
+// wrong quiz answer about a learned concept uses it.
+func (l *Learned) BringForward(now time.Time) {
+	elapsed := int(now.Sub(l.LearnedAt) / (24 * time.Hour))
+	l.ExpiryDays = max(1, min(l.ExpiryDays, elapsed))
+}
+
+// DueAt is when a learned concept is due for review.
+func (l *Learned) DueAt() time.Time {
+	return l.LearnedAt.Add(time.Duration(l.ExpiryDays) * 24 * time.Hour)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go-concept-trainer/concepts"
+	"go-concept-trainer/progress"
+)
+
+// quizQuestion is what the browser sees of a quiz question before it is
+// answered.
+type quizQuestion struct {
+	Prompt  string   `json:"prompt"`
+	Choices []string `json:"choices"`
+}
+
+func quizView(questions []concepts.Question) []quizQuestion {
+	var out []quizQuestion
+	for _, q := range questions {
+		out = append(out, quizQuestion{Prompt: q.Prompt, Choices: q.Choices})
+	}
+	return out
+}
+
+// quizAnswerRequest is the body of POST /api/quiz/{id}/answer. Question and
+// Choice are indexes.
+type quizAnswerRequest struct {
+	Question int `json:"question"`
+	Choice   int `json:"choice"`
+}
+
+type quizAnswerResult struct {
+	Correct   bool   `json:"correct"`
+	Answer    int    `json:"answer"`
+	Rationale string `json:"rationale"`
+	// Learned is the rescheduled record when a miss brought a learned
+	// concept's review forward.
+	Learned *progress.Learned `json:"learned,omitempty"`
+}
+
+// answerQuiz serves POST /api/quiz/{id}/answer. It grades one answer and
+// reveals the right choice and rationale. A wrong answer counts as a quiz
+// miss, which shortens the interval the concept is next learned with; if
+// the concept is already learned it also brings its review forward. Misses
+// are only recorded for learners the server already holds progress for.
+func (p *progressAPI) answerQuiz(w http.ResponseWriter, r *http.Request) {
+	i, ok := p.idx.byID[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "concept not found")
+		return
+	}
+	c := &p.idx.list[i]
+	var req quizAnswerRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.Question < 0 || req.Question >= len(c.Questions) {
+		writeError(w, http.StatusNotFound, "question not found")
+		return
+	}
+	q := c.Questions[req.Question]
+	if req.Choice < 0 || req.Choice >= len(q.Choices) {
+		writeError(w, http.StatusBadRequest, "choice out of range")
+		return
+	}
+
+	res := quizAnswerResult{Correct: req.Choice == q.Answer, Answer: q.Answer, Rationale: q.Rationale}
+	learner, ok, err := p.storedLearner(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "could not read stored progress")
+		return
+	}
+	if !res.Correct && ok {
+		err := p.store.update(learner, func(f *progress.File) error {
+			rec := f.Concepts[c.ID]
+			rec.QuizMisses++
+			if rec.Learned != nil {
+				l := *rec.Learned
+				l.BringForward(time.Now())
+				rec.Learned = &l
+				res.Learned = &l
+			}
+			f.Concepts[c.ID] = rec
+			return nil
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "could not store progress")
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, res)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go-concept-trainer/integrity"
+)
+
+// replay is the editor log of a learner's latest pass of a concept.
+type replay struct {
+	At      time.Time         `json:"at"` // matches the attempt in the history
+	Signals integrity.Signals `json:"signals"`
+	integrity.Log
+}
+
+// maxCachedReplays bounds how many replays a replayStore with a directory
+// keeps in memory.
+const maxCachedReplays = 1024
+
+// replayStore keeps each learner's latest replay per concept. With a
+// directory it persists one file per learner and concept and caches up to
+// maxCachedReplays of them; otherwise it lives in memory only, until the
+// process exits.
+type replayStore struct {
+	dir string
+
+	mu      sync.Mutex
+	replays map[string]*replay // learner/concept
+}
+
+func newReplayStore(dir string) (*replayStore, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, err
+		}
+	}
+	return &replayStore{dir: dir, replays: make(map[string]*replay)}, nil
+}
+
+func (s *replayStore) get(learner, id string) (*replay, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.replays[learner+"/"+id]; ok {
+		return r, nil
+	}
+	if s.dir == "" {
+		return nil, os.ErrNotExist
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, learner, id+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var r replay
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	s.cache(learner+"/"+id, &r)
+	return &r, nil
+}
+
+// cache remembers r, emptying a full cache when replays are on disk as
+// progressStore.cache does.
+func (s *replayStore) cache(key string, r *replay) {
+	if s.dir != "" && len(s.replays) >= maxCachedReplays {
+		clear(s.replays)
+	}
+	s.replays[key] = r
+}
+
+func (s *replayStore) save(learner, id string, r *replay) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dir != "" {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		dir := filepath.Join(s.dir, learner)
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return err
+		}
+		tmp := filepath.Join(dir, id+".json.tmp")
+		if err := os.WriteFile(tmp, data, 0o640); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, filepath.Join(dir, id+".json")); err != nil {
+			return err
+		}
+	}
+	s.cache(learner+"/"+id, r)
+	return nil
+}
+
+// replay serves GET /api/replay/{id}: the editor log of the learner's
+// latest pass of the concept, with its integrity signals, for the browser
+// to play back.
+func (p *progressAPI) replay(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !p.known(id) {
+		writeError(w, http.StatusNotFound, "concept not found")
+		return
+	}
+	learner, ok := learnerID(r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "no replay for this concept")
+		return
+	}
+	rep, err := p.replays.get(learner, id)
+	if errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusNotFound, "no replay for this concept")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "could not read the replay")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, rep)
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+)
+
+// signaled always reports false: the child is only limited by rlimits,
+// and so only killed at one, on Unix.
+func signaled(*exec.ExitError) (os.Signal, bool) { return nil, false }
+
+func cpuLimitSignal(os.Signal) bool { return false }
@@ -0,0 +1,25 @@
+//go:build unix
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// signaled returns the signal that killed the child, if one did.
+func signaled(exit *exec.ExitError) (os.Signal, bool) {
+	ws, ok := exit.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return nil, false
+	}
+	return ws.Signal(), true
+}
+
+// cpuLimitSignal reports whether sig is how the kernel enforces
+// RLIMIT_CPU: SIGXCPU at the soft limit, SIGKILL at the hard one. The child
+// sets both to the same value, so it normally gets SIGKILL.
+func cpuLimitSignal(sig os.Signal) bool {
+	return sig == syscall.SIGKILL || sig == syscall.SIGXCPU
+}
@@ -0,0 +1,189 @@
+// Package runner executes learner programs on the server by handing them to
+// the sandbox-runner command (built from the wasm module), which interprets
+// them with the same package whitelist as the browser. Each run is a fresh
+// child process with an empty environment, a temporary working directory,
+// a wall-clock timeout and hard limits on its CPU time, memory and threads.
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrBusy is returned when every execution slot stays taken for as long as
+// the caller can wait.
+var ErrBusy = errors.New("runner: too many programs running")
+
+// Result is what a program printed and why it stopped, if it failed.
+type Result struct {
+	Output   string `json:"output"`
+	Error    string `json:"error,omitempty"`
+	TimedOut bool   `json:"timedOut,omitempty"`
+}
+
+// Limits bound each run. Zero means no limit.
+type Limits struct {
+	Timeout time.Duration // wall clock
+	Wait    time.Duration // for a free slot, after which Run returns ErrBusy
+	CPU     time.Duration // CPU time, rounded up to whole seconds
+	Memory  int64         // bytes of heap and other private memory
+	Threads int           // OS threads
+}
+
+// Runner runs programs through a sandbox-runner binary.
+type Runner struct {
+	path   string
+	limits Limits
+	args   []string
+	env    []string
+	slots  chan struct{}
+}
+
+// New returns a Runner for the sandbox-runner binary at path, running at
+// most parallel programs at once within limits. The child applies the CPU,
+// memory and thread limits to itself as hard limits before the program
+// starts, and gets GOMEMLIMIT a quarter below the memory limit so the
+// garbage collector works harder before it is reached. It also runs on a
+// single CPU, so parallel runs cannot starve the server.
+func New(path string, parallel int, limits Limits) *Runner {
+	r := &Runner{path: path, limits: limits, env: []string{"GOMAXPROCS=1"}, slots: make(chan struct{}, max(1, parallel))}
+	if limits.CPU > 0 {
+		secs := int64((limits.CPU + time.Second - 1) / time.Second)
+		r.args = append(r.args, "-max-cpu", strconv.FormatInt(secs, 10))
+	}
+	if limits.Memory > 0 {
+		r.args = append(r.args, "-max-memory", strconv.FormatInt(limits.Memory, 10))
+		r.env = append(r.env, "GOMEMLIMIT="+strconv.FormatInt(limits.Memory/4*3, 10))
+	}
+	if limits.Threads > 0 {
+		r.args = append(r.args, "-max-threads", strconv.Itoa(limits.Threads))
+	}
+	return r
+}
+
+// Find locates the sandbox-runner binary: next to the running executable
+// first, then on PATH. It returns "" if there is none.
+func Find() string {
+	if exe, err := os.Executable(); err == nil {
+		p := filepath.Join(filepath.Dir(exe), "sandbox-runner")
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	if p, err := exec.LookPath("sandbox-runner"); err == nil {
+		return p
+	}
+	return ""
+}
+
+// maxResult bounds the JSON read back from the child; the child caps the
+// program's own output below this.
+const maxResult = 1 << 20
+
+// Run executes code. A program that fails to compile, panics, runs out of
+// time or hits a limit is a normal Result; the error is for problems
+// running the sandbox.
+func (r *Runner) Run(ctx context.Context, code string) (Result, error) {
+	wait := ctx
+	if r.limits.Wait > 0 {
+		var cancel context.CancelFunc
+		wait, cancel = context.WithTimeout(ctx, r.limits.Wait)
+		defer cancel()
+	}
+	select {
+	case r.slots <- struct{}{}:
+		defer func() { <-r.slots }()
+	case <-wait.Done():
+		return Result{}, ErrBusy
+	}
+
+	dir, err := os.MkdirTemp("", "run-")
+	if err != nil {
+		return Result{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	if r.limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.limits.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, r.path, r.args...)
+	cmd.Dir = dir
+	cmd.Env = r.env
+	cmd.Stdin = strings.NewReader(code)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &limitedBuffer{buf: &stdout, n: maxResult}
+	cmd.Stderr = &limitedBuffer{buf: &stderr, n: maxStderr}
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return Result{TimedOut: true, Error: fmt.Sprintf("timed out after %s", r.limits.Timeout)}, nil
+	}
+	// sandbox-runner exits 1 when it cannot run at all; any other failure
+	// is the program's.
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() != 1 {
+		return Result{Error: failure(exit, stderr.String())}, nil
+	}
+	if err != nil {
+		return Result{}, fmt.Errorf("runner: %w", err)
+	}
+
+	var res Result
+	if err := json.Unmarshal(stdout.Bytes(), &res); err != nil {
+		return Result{}, fmt.Errorf("runner: reading result: %w", err)
+	}
+	return res, nil
+}
+
+// maxStderr bounds the stderr kept from the child. Only the message before
+// the first blank line is used; the goroutine dump after it is the
+// interpreter's.
+const maxStderr = 4 << 10
+
+// failure describes a run the child did not finish. The kernel kills it at
+// the CPU limit. The Go runtime exits 2 both when it aborts at the memory or
+// thread limit and when the program panics in a goroutine of its own, which
+// the interpreter cannot recover; stderr tells them apart.
+func failure(exit *exec.ExitError, stderr string) string {
+	if sig, ok := signaled(exit); ok {
+		if cpuLimitSignal(sig) {
+			return "program exceeded the sandbox's CPU time limit"
+		}
+		return "program was killed by " + sig.String()
+	}
+	msg, _, _ := strings.Cut(strings.TrimSpace(stderr), "\n\n")
+	switch {
+	case exit.ExitCode() != 2 || msg == "":
+		return fmt.Sprintf("program exited with status %d", exit.ExitCode())
+	case strings.Contains(msg, "fatal error: runtime: out of memory"):
+		return "program exceeded the sandbox's memory limit"
+	case strings.Contains(msg, "fatal error: thread exhaustion"):
+		return "program exceeded the sandbox's thread limit"
+	}
+	return msg
+}
+
+// limitedBuffer discards writes beyond n bytes.
+type limitedBuffer struct {
+	buf *bytes.Buffer
+	n   int
+}
+
+func (l *limitedBuffer) Write(p []byte) (int, error) {
+	if l.n > 0 {
+		w := min(len(p), l.n)
+		l.buf.Write(p[:w])
+		l.n -= w
+	}
+	return len(p), nil
+}
@@ -0,0 +1,37 @@
+package runner
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestFailure(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	tests := []struct {
+		name, script, stderr, want string
+	}{
+		{"cpu limit", "kill -KILL $$", "", "program exceeded the sandbox's CPU time limit"},
+		{"other signal", "kill -TERM $$", "", "program was killed by terminated"},
+		{"goroutine panic", "exit 2", "panic: boom\n\ngoroutine 7 [running]:\nmain.main()\n", "panic: boom"},
+		{"fatal error", "exit 2", "fatal error: concurrent map writes\n\ngoroutine 9 [running]:\n", "fatal error: concurrent map writes"},
+		{"memory limit", "exit 2", "fatal error: runtime: out of memory\n\nruntime stack:\n", "program exceeded the sandbox's memory limit"},
+		{"thread limit", "exit 2", "runtime: program exceeds 64-thread limit\nfatal error: thread exhaustion\n\n", "program exceeded the sandbox's thread limit"},
+		{"silent", "exit 2", "", "program exited with status 2"},
+		{"other status", "exit 3", "panic: boom", "program exited with status 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := exec.Command("sh", "-c", tt.script).Run()
+			var exit *exec.ExitError
+			if !errors.As(err, &exit) {
+				t.Fatalf("sh -c %q: %v", tt.script, err)
+			}
+			if got := failure(exit, tt.stderr); got != tt.want {
+				t.Errorf("failure = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
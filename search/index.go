@@ -0,0 +1,159 @@
+// Package search implements a small in-memory inverted index with BM25F
+// ranking and highlighted snippets, sized for the concept catalogue.
+package search
+
+import (
+	"math"
+	"sort"
+)
+
+// BM25 parameters.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Field describes one indexed text field and its relative importance.
+type Field struct {
+	Name   string
+	Weight float64
+}
+
+// Result is one ranked match.
+type Result struct {
+	ID      string
+	Score   float64
+	Field   string    // field the snippet was taken from
+	Snippet []Segment // snippet text split into plain and matching runs
+}
+
+type document struct {
+	id     string
+	values []string  // raw field values, for snippets
+	length []float64 // token count per field
+}
+
+type posting struct {
+	doc int
+	tf  []float64 // term frequency per field
+}
+
+// Index is an inverted index over documents with a fixed set of fields. It is
+// built once and then only read, so it is safe for concurrent searches after
+// the last Add.
+type Index struct {
+	fields   []Field
+	docs     []document
+	postings map[string][]posting
+	total    []float64 // summed field lengths, for average length
+}
+
+// New returns an empty index over fields.
+func New(fields ...Field) *Index {
+	return &Index{
+		fields:   fields,
+		postings: make(map[string][]posting),
+		total:    make([]float64, len(fields)),
+	}
+}
+
+// Add indexes a document. values must be given in the same order as the
+// index fields.
+func (ix *Index) Add(id string, values ...string) {
+	doc := len(ix.docs)
+	d := document{id: id, values: values, length: make([]float64, len(ix.fields))}
+	tfs := make(map[string][]float64)
+	for f := range ix.fields {
+		if f >= len(values) {
+			break
+		}
+		for _, sp := range tokenize(values[f]) {
+			tf, ok := tfs[sp.term]
+			if !ok {
+				tf = make([]float64, len(ix.fields))
+				tfs[sp.term] = tf
+			}
+			tf[f]++
+			d.length[f]++
+		}
+		ix.total[f] += d.length[f]
+	}
+	for term, tf := range tfs {
+		ix.postings[term] = append(ix.postings[term], posting{doc: doc, tf: tf})
+	}
+	ix.docs = append(ix.docs, d)
+}
+
+// Search ranks documents against query and returns at most limit results,
+// best first.
+func (ix *Index) Search(query string, limit int) []Result {
+	terms := uniqueTerms(Tokenize(query))
+	if len(terms) == 0 || len(ix.docs) == 0 {
+		return nil
+	}
+
+	n := float64(len(ix.docs))
+	avg := make([]float64, len(ix.fields))
+	for f := range ix.fields {
+		avg[f] = math.Max(ix.total[f]/n, 1)
+	}
+
+	scores := make(map[int]float64)
+	for _, term := range terms {
+		plist := ix.postings[term]
+		if len(plist) == 0 {
+			continue
+		}
+		df := float64(len(plist))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for _, p := range plist {
+			d := ix.docs[p.doc]
+			var wtf float64
+			for f, field := range ix.fields {
+				if p.tf[f] == 0 {
+					continue
+				}
+				norm := 1 - b + b*d.length[f]/avg[f]
+				wtf += field.Weight * p.tf[f] / norm
+			}
+			scores[p.doc] += idf * wtf * (k1 + 1) / (wtf + k1)
+		}
+	}
+
+	ranked := make([]int, 0, len(scores))
+	for doc := range scores {
+		ranked = append(ranked, doc)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if scores[ranked[i]] != scores[ranked[j]] {
+			return scores[ranked[i]] > scores[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	want := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		want[t] = true
+	}
+	results := make([]Result, len(ranked))
+	for i, doc := range ranked {
+		field, snippet := ix.snippet(ix.docs[doc], want)
+		results[i] = Result{ID: ix.docs[doc].id, Score: scores[doc], Field: field, Snippet: snippet}
+	}
+	return results
+}
+
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	out := terms[:0]
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,198 @@
+package search
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"sync.WaitGroup", []string{"sync.waitgroup", "sync", "waitgroup", "wait", "group"}},
+		{"HTTPServer", []string{"httpserver", "http", "server"}},
+		{"read_all", []string{"read_all", "read", "all"}},
+		{"Start the goroutines.", []string{"start", "goroutine"}},
+		{"a mutex is a lock", []string{"mutex", "lock"}},
+		{"class access", []string{"class", "access"}},
+		{"end. Next", []string{"end", "next"}},
+		{"utf8 décodé", []string{"utf8", "utf", "décodé"}},
+		{"  ", []string{}},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func newTestIndex(docs ...[3]string) *Index {
+	ix := New(Field{Name: "name", Weight: 3}, Field{Name: "body", Weight: 1})
+	for _, d := range docs {
+		ix.Add(d[0], d[1], d[2])
+	}
+	return ix
+}
+
+func ids(results []Result) []string {
+	out := make([]string, len(results))
+	for i, r := range results {
+		out[i] = r.ID
+	}
+	return out
+}
+
+func TestSearchScore(t *testing.T) {
+	ix := newTestIndex(
+		[3]string{"mutex", "Mutex", "lock a mutex"},
+		[3]string{"channels", "Channel", "send on a channel"},
+	)
+	got := ix.Search("mutex", 0)
+	if len(got) != 1 || got[0].ID != "mutex" {
+		t.Fatalf("Search(mutex) = %v, want only the mutex document", ids(got))
+	}
+	// One document of two has the term: idf = ln(1 + 1.5/1.5). Both
+	// fields are of average length, so the weighted term frequency is
+	// 3*1 + 1*1.
+	idf, wtf := math.Log(2), 4.0
+	want := idf * wtf * (k1 + 1) / (wtf + k1)
+	if math.Abs(got[0].Score-want) > 1e-9 {
+		t.Errorf("score = %v, want %v", got[0].Score, want)
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	tests := []struct {
+		name  string
+		docs  [][3]string
+		query string
+		want  []string
+	}{
+		{
+			name: "name outweighs body",
+			docs: [][3]string{
+				{"body", "Locks", "a mutex guards shared state"},
+				{"name", "Mutex", "guards shared state"},
+			},
+			query: "mutex",
+			want:  []string{"name", "body"},
+		},
+		{
+			name: "rare terms count for more",
+			docs: [][3]string{
+				{"common", "Loops", "loop over a slice"},
+				{"rare", "Select", "wait on channels"},
+				{"other", "Range", "loop over a map"},
+			},
+			query: "loop channel",
+			want:  []string{"rare", "common", "other"},
+		},
+		{
+			name: "a name match beats a shorter body",
+			docs: [][3]string{
+				{"long", "Defer", "defer runs a call when the surrounding function returns, after its result is set"},
+				{"short", "Cleanup", "defer runs a call later"},
+			},
+			query: "defer",
+			want:  []string{"long", "short"},
+		},
+		{
+			name: "shorter fields rank higher",
+			docs: [][3]string{
+				{"long", "Cleanup", "defer runs a call when the surrounding function returns, after its result is set"},
+				{"short", "Cleanup", "defer runs a call later"},
+			},
+			query: "defer",
+			want:  []string{"short", "long"},
+		},
+		{
+			name: "ties keep insertion order",
+			docs: [][3]string{
+				{"first", "Maps", "keys and values"},
+				{"second", "Maps", "keys and values"},
+			},
+			query: "maps",
+			want:  []string{"first", "second"},
+		},
+		{
+			name: "identifier parts match",
+			docs: [][3]string{
+				{"wg", "sync.WaitGroup", "wait for goroutines"},
+				{"mu", "sync.Mutex", "lock state"},
+			},
+			query: "waitgroup",
+			want:  []string{"wg"},
+		},
+		{
+			name:  "stop words alone match nothing",
+			docs:  [][3]string{{"x", "The", "is a"}},
+			query: "the is",
+			want:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ix := newTestIndex(tt.docs...)
+			if got := ids(ix.Search(tt.query, 0)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchSaturates(t *testing.T) {
+	ix := newTestIndex(
+		[3]string{"once", "", "channel"},
+		[3]string{"often", "", "channel channel channel channel channel channel channel channel"},
+		[3]string{"none", "", "mutex"},
+	)
+	got := ix.Search("channel", 0)
+	if !reflect.DeepEqual(ids(got), []string{"often", "once"}) {
+		t.Fatalf("Search = %q, want [often once]", ids(got))
+	}
+	// However often a term repeats, its score stays below (k1+1) times
+	// its idf.
+	idf := math.Log(1 + (3-2+0.5)/(2+0.5))
+	if got[0].Score >= idf*(k1+1) || got[0].Score > 2*got[1].Score {
+		t.Errorf("scores %v and %v do not saturate", got[0].Score, got[1].Score)
+	}
+}
+
+func TestSearchLimit(t *testing.T) {
+	ix := newTestIndex(
+		[3]string{"a", "Slices", ""},
+		[3]string{"b", "Slices", ""},
+		[3]string{"c", "Slices", ""},
+	)
+	if got := ids(ix.Search("slice", 2)); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Search with limit 2 = %q", got)
+	}
+	if got := ix.Search("", 0); got != nil {
+		t.Errorf("empty query = %v, want nil", got)
+	}
+	if got := New(Field{Name: "name", Weight: 1}).Search("slice", 0); got != nil {
+		t.Errorf("empty index = %v, want nil", got)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	ix := newTestIndex([3]string{"wg", "WaitGroup", "A WaitGroup waits for a collection of goroutines to finish."})
+	got := ix.Search("goroutines", 0)
+	if len(got) != 1 {
+		t.Fatalf("Search = %v", ids(got))
+	}
+	if got[0].Field != "body" {
+		t.Errorf("snippet field = %q, want body", got[0].Field)
+	}
+	var hits []string
+	for _, s := range got[0].Snippet {
+		if s.Hit {
+			hits = append(hits, s.Text)
+		}
+	}
+	if !reflect.DeepEqual(hits, []string{"goroutines"}) {
+		t.Errorf("highlighted %q, want [goroutines] in %+v", hits, got[0].Snippet)
+	}
+}
@@ -0,0 +1,152 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// snippetLen is the target snippet length in bytes.
+const snippetLen = 180
+
+// Segment is a run of snippet text; Hit marks runs that matched the query.
+type Segment struct {
+	Text string `json:"text"`
+	Hit  bool   `json:"hit,omitempty"`
+}
+
+// snippet picks the field with the most distinct query terms (ties go to the
+// heavier field) and returns a window around its densest cluster of hits.
+func (ix *Index) snippet(d document, want map[string]bool) (string, []Segment) {
+	bestField, bestDistinct := -1, 0
+	var bestHits []span
+	for f := range ix.fields {
+		if f >= len(d.values) {
+			break
+		}
+		var hits []span
+		distinct := make(map[string]bool)
+		for _, sp := range tokenize(d.values[f]) {
+			if want[sp.term] {
+				distinct[sp.term] = true
+				if len(hits) == 0 || hits[len(hits)-1].start != sp.start {
+					hits = append(hits, sp)
+				}
+			}
+		}
+		if len(distinct) > bestDistinct ||
+			len(distinct) == bestDistinct && bestField >= 0 && len(distinct) > 0 && ix.fields[f].Weight > ix.fields[bestField].Weight {
+			bestField, bestDistinct, bestHits = f, len(distinct), hits
+		}
+	}
+	if bestField < 0 {
+		// Nothing to highlight; fall back to the opening of the first field.
+		if len(d.values) == 0 {
+			return "", nil
+		}
+		text := d.values[0]
+		end := clampEnd(text, snippetLen)
+		return ix.fields[0].Name, []Segment{{Text: squash(text[:end]) + ellipsisIf(end < len(text))}}
+	}
+
+	text := d.values[bestField]
+	// Densest window: the hit after which the most hits fit in snippetLen.
+	first, count := 0, 0
+	for i := range bestHits {
+		c := 0
+		for j := i; j < len(bestHits) && bestHits[j].end-bestHits[i].start <= snippetLen; j++ {
+			c++
+		}
+		if c > count {
+			first, count = i, c
+		}
+	}
+	start := clampStart(text, bestHits[first].start-snippetLen/4)
+	end := clampEnd(text, start+snippetLen)
+
+	var segs []Segment
+	if start > 0 {
+		segs = append(segs, Segment{Text: "…"})
+	}
+	pos := start
+	for _, h := range bestHits {
+		if h.start < pos || h.end > end {
+			continue
+		}
+		if h.start > pos {
+			segs = append(segs, Segment{Text: squash(text[pos:h.start])})
+		}
+		segs = append(segs, Segment{Text: text[h.start:h.end], Hit: true})
+		pos = h.end
+	}
+	if pos < end {
+		segs = append(segs, Segment{Text: squash(text[pos:end])})
+	}
+	if end < len(text) {
+		segs = append(segs, Segment{Text: "…"})
+	}
+	return ix.fields[bestField].Name, segs
+}
+
+// clampStart moves i back to the start of a word, never below 0.
+func clampStart(s string, i int) int {
+	if i <= 0 {
+		return 0
+	}
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	for i > 0 {
+		r, _ := utf8.DecodeLastRuneInString(s[:i])
+		if unicode.IsSpace(r) {
+			break
+		}
+		i -= utf8.RuneLen(r)
+	}
+	return i
+}
+
+// clampEnd moves i forward to the end of a word, never past len(s).
+func clampEnd(s string, i int) int {
+	if i >= len(s) {
+		return len(s)
+	}
+	for i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
+	}
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if unicode.IsSpace(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// squash collapses runs of whitespace (examples contain newlines and
+// indentation) to single spaces, keeping a leading or trailing space.
+func squash(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s != "" {
+			return " "
+		}
+		return ""
+	}
+	out := strings.Join(fields, " ")
+	if r, _ := utf8.DecodeRuneInString(s); unicode.IsSpace(r) {
+		out = " " + out
+	}
+	if r, _ := utf8.DecodeLastRuneInString(s); unicode.IsSpace(r) {
+		out += " "
+	}
+	return out
+}
+
+func ellipsisIf(b bool) string {
+	if b {
+		return "…"
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// span is a token together with the byte range of the source text it came
+// from. Several tokens can share a span when one identifier expands into
+// multiple terms.
+type span struct {
+	term       string
+	start, end int
+}
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "can": true, "do": true, "for": true, "from": true,
+	"how": true, "i": true, "if": true, "in": true, "into": true, "is": true,
+	"it": true, "its": true, "of": true, "on": true, "or": true, "so": true,
+	"that": true, "the": true, "then": true, "this": true, "to": true,
+	"use": true, "was": true, "what": true, "when": true, "with": true,
+	"you": true, "your": true,
+}
+
+// Tokenize splits s into normalised search terms. It understands Go
+// identifiers: "sync.WaitGroup" yields "sync.waitgroup", "sync", "waitgroup",
+// "wait" and "group", so any of those spellings finds it.
+func Tokenize(s string) []string {
+	spans := tokenize(s)
+	terms := make([]string, len(spans))
+	for i, sp := range spans {
+		terms[i] = sp.term
+	}
+	return terms
+}
+
+func tokenize(s string) []span {
+	var out []span
+	i := 0
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !isIdentRune(r) {
+			i += size
+			continue
+		}
+		start := i
+		for i < len(s) {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if isIdentRune(r) {
+				i += size
+				continue
+			}
+			// A dot joins identifiers only when another identifier follows,
+			// so "sync.Mutex" is one run but a sentence-ending "." is not.
+			if r == '.' && i+1 < len(s) {
+				if next, _ := utf8.DecodeRuneInString(s[i+1:]); isIdentRune(next) {
+					i += size
+					continue
+				}
+			}
+			break
+		}
+		for _, t := range expand(s[start:i]) {
+			out = append(out, span{term: t, start: start, end: i})
+		}
+	}
+	return out
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// expand turns one identifier run into its distinct normalised terms.
+func expand(word string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	add := func(t string) {
+		t = normalize(t)
+		if t != "" && !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+
+	add(word)
+	parts := strings.FieldsFunc(word, func(r rune) bool { return r == '.' || r == '_' })
+	for _, p := range parts {
+		if len(parts) > 1 {
+			add(p)
+		}
+		if sub := splitCamel(p); len(sub) > 1 {
+			for _, s := range sub {
+				add(s)
+			}
+		}
+	}
+	return terms
+}
+
+// splitCamel splits "WaitGroup" into "Wait", "Group" and "HTTPServer" into
+// "HTTP", "Server".
+func splitCamel(s string) []string {
+	runes := []rune(s)
+	var parts []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		boundary := unicode.IsLower(prev) && unicode.IsUpper(cur) ||
+			unicode.IsLetter(prev) != unicode.IsLetter(cur) ||
+			i+1 < len(runes) && unicode.IsUpper(prev) && unicode.IsUpper(cur) && unicode.IsLower(runes[i+1])
+		if boundary {
+			parts = append(parts, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(parts, string(runes[start:]))
+}
+
+// normalize lowercases a term, drops stop words and single characters, and
+// strips a plural "s" so "goroutines" matches "goroutine".
+func normalize(t string) string {
+	t = strings.ToLower(strings.Trim(t, "._"))
+	if utf8.RuneCountInString(t) < 2 || stopWords[t] {
+		return ""
+	}
+	if len(t) > 3 && strings.HasSuffix(t, "s") && !strings.HasSuffix(t, "ss") {
+		t = t[:len(t)-1]
+	}
+	return t
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-concept-trainer/progress"
+)
+
+const (
+	defaultSessionMinutes = 20
+	// weakCategories is how many of the learner's weakest categories a
+	// session revisits.
+	weakCategories = 2
+	// maxTimezoneOffset bounds ?tz=, in minutes east of UTC.
+	maxTimezoneOffset = 14 * 60
+)
+
+// conceptMinutes estimates how long a concept takes to solve from scratch.
+// A review takes half as long.
+var conceptMinutes = map[string]int{
+	"beginner":     3,
+	"intermediate": 6,
+	"advanced":     10,
+}
+
+// Reasons a concept is in a session.
+const (
+	reasonReview = "review" // due for review, or changed since it was learned
+	reasonWeak   = "weak"   // learned with help in one of the weakest categories
+	reasonNew    = "new"    // not learned yet and its prerequisites are
+)
+
+// sessionItem is one concept in a daily session.
+type sessionItem struct {
+	ID         string `json:"id"`
+	Number     int    `json:"number"`
+	Name       string `json:"name"`
+	Category   string `json:"category"`
+	Difficulty string `json:"difficulty"`
+	Reason     string `json:"reason"`
+	Note       string `json:"note"`
+	Minutes    int    `json:"minutes"`
+	Done       bool   `json:"done"`
+}
+
+// session is a learner's plan for one day. It stays fixed for the day
+// unless the budget or the difficulty filter changes; items are done once
+// the concept is marked learned after the session was built.
+type session struct {
+	Date           string        `json:"date"` // in the learner's time zone
+	Minutes        int           `json:"minutes"`
+	Difficulties   []string      `json:"difficulties"`
+	CreatedAt      time.Time     `json:"createdAt"`
+	PlannedMinutes int           `json:"plannedMinutes"`
+	Items          []sessionItem `json:"items"`
+	Completed      int           `json:"completed"`
+	Complete       bool          `json:"complete"`
+}
+
+// sessionRetention is how long a session is kept after it was planned. It
+// is planned for one day, in the learner's time zone.
+const sessionRetention = 48 * time.Hour
+
+// sessionStore holds each learner's latest session in memory, for
+// sessionRetention.
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+func newSessionStore() *sessionStore {
+	s := &sessionStore{sessions: make(map[string]*session)}
+	go func() {
+		for range time.Tick(time.Hour) {
+			s.mu.Lock()
+			now := time.Now()
+			maps.DeleteFunc(s.sessions, func(_ string, ss *session) bool {
+				return now.Sub(ss.CreatedAt) > sessionRetention
+			})
+			s.mu.Unlock()
+		}
+	}()
+	return s
+}
+
+// today serves GET /api/session/today. ?minutes= sets the time budget,
+// falling back to the learner's sessionMinutes setting and then to 20;
+// ?difficulty= is a comma-separated list of the difficulties to include,
+// all of them if empty; ?tz= is the learner's UTC offset in minutes east,
+// which decides when the day starts. The session is built from concepts
+// due for review first, then concepts that needed help in the weakest
+// categories, then the next new concepts whose prerequisites are learned,
+// in curriculum order, until the budget is spent.
+func (p *progressAPI) today(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	tz, err := queryInt(q.Get("tz"), 0)
+	if err != nil || tz < -maxTimezoneOffset || tz > maxTimezoneOffset {
+		writeError(w, http.StatusBadRequest, "tz must be a UTC offset in minutes")
+		return
+	}
+	minutes, err := queryInt(q.Get("minutes"), 0)
+	if err != nil || minutes != 0 && (minutes < progress.MinSessionMinutes || minutes > progress.MaxSessionMinutes) {
+		writeError(w, http.StatusBadRequest, "minutes must be between 5 and 240")
+		return
+	}
+	difficulties, err := parseDifficulties(q.Get("difficulty"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// A browser the server holds no progress for gets a plan from scratch
+	// that is not kept.
+	learner, known, err := p.storedLearner(r)
+	f := progress.New()
+	if err == nil && known {
+		f, err = p.store.get(learner)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "could not read stored progress")
+		return
+	}
+	if minutes == 0 && f.Settings != nil {
+		minutes = f.Settings.SessionMinutes
+	}
+	if minutes == 0 {
+		minutes = defaultSessionMinutes
+	}
+
+	now := time.Now().UTC()
+	date := now.Add(time.Duration(tz) * time.Minute).Format(time.DateOnly)
+	p.sessions.mu.Lock()
+	defer p.sessions.mu.Unlock()
+	s, ok := p.sessions.sessions[learner]
+	if !ok || s.Date != date || s.Minutes != minutes || !slices.Equal(s.Difficulties, difficulties) {
+		s = p.plan(f, now, minutes, difficulties)
+		s.Date = date
+		if known {
+			p.sessions.sessions[learner] = s
+		}
+	}
+	s.Completed = 0
+	for i := range s.Items {
+		it := &s.Items[i]
+		l := f.Concepts[it.ID].Learned
+		it.Done = l != nil && !l.LearnedAt.Before(s.CreatedAt)
+		if it.Done {
+			s.Completed++
+		}
+	}
+	s.Complete = s.Completed == len(s.Items)
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, s)
+}
+
+// parseDifficulties reads a comma-separated ?difficulty= list, sorted and
+// without duplicates. Empty means every difficulty.
+func parseDifficulties(s string) ([]string, error) {
+	var difficulties []string
+	for d := range strings.SplitSeq(strings.ToLower(s), ",") {
+		if d = strings.TrimSpace(d); d == "" {
+			continue
+		}
+		if _, ok := conceptMinutes[d]; !ok {
+			return nil, errors.New("unknown difficulty " + strconv.Quote(d))
+		}
+		difficulties = append(difficulties, d)
+	}
+	slices.Sort(difficulties)
+	return slices.Compact(difficulties), nil
+}
+
+func queryInt(s string, def int) (int, error) {
+	if s == "" {
+		return def, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// plan builds a session from the learner's progress f.
+func (p *progressAPI) plan(f *progress.File, now time.Time, minutes int, difficulties []string) *session {
+	allowed := func(c *Concept) bool {
+		return len(difficulties) == 0 || slices.Contains(difficulties, c.Difficulty)
+	}
+	// shaky reports whether a learned concept needed help.
+	shaky := func(l *progress.Learned) bool { return l.Assisted || l.QuizMisses > 0 }
+
+	type candidate struct {
+		c      *Concept
+		reason string
+		note   string
+		rank   float64 // lower first within a reason
+	}
+	var reviews, weak, fresh []candidate
+
+	// How many learned concepts per category needed help, and of how many.
+	helped, learned := make(map[string]int), make(map[string]int)
+	for i := range p.idx.list {
+		c := &p.idx.list[i]
+		l := f.Concepts[c.ID].Learned
+		if l == nil {
+			continue
+		}
+		learned[c.Category]++
+		if shaky(l) {
+			helped[c.Category]++
+		}
+	}
+	var weakest []string
+	for cat := range helped {
+		weakest = append(weakest, cat)
+	}
+	slices.SortFunc(weakest, func(a, b string) int {
+		ra, rb := float64(helped[a])/float64(learned[a]), float64(helped[b])/float64(learned[b])
+		return cmp.Or(cmp.Compare(rb, ra), cmp.Compare(helped[b], helped[a]), strings.Compare(a, b))
+	})
+	weakest = weakest[:min(weakCategories, len(weakest))]
+
+	unlocked := func(c *Concept) bool {
+		for _, id := range c.Prerequisites {
+			if _, ok := p.idx.byID[id]; ok && f.Concepts[id].Learned == nil {
+				return false
+			}
+		}
+		return true
+	}
+
+	for i := range p.idx.list {
+		c := &p.idx.list[i]
+		if !allowed(c) {
+			continue
+		}
+		l := f.Concepts[c.ID].Learned
+		switch {
+		case l == nil:
+			if unlocked(c) {
+				fresh = append(fresh, candidate{c, reasonNew, "Next in " + c.Category, float64(c.Number)})
+			}
+		case l.Version != "" && l.Version != c.Version:
+			reviews = append(reviews, candidate{c, reasonReview, "Changed since you learned it", 0})
+		case !now.Before(l.DueAt()):
+			reviews = append(reviews, candidate{c, reasonReview, "Due for review", float64(l.DueAt().Unix())})
+		case shaky(l) && slices.Contains(weakest, c.Category):
+			weak = append(weak, candidate{c, reasonWeak, "You needed help with " + c.Category, -float64(l.HintLevel + l.QuizMisses)})
+		}
+	}
+	byRank := func(a, b candidate) int { return cmp.Compare(a.rank, b.rank) }
+	slices.SortStableFunc(reviews, byRank)
+	slices.SortStableFunc(weak, byRank)
+
+	s := &session{Minutes: minutes, Difficulties: difficulties, CreatedAt: now, Items: []sessionItem{}}
+	if s.Difficulties == nil {
+		s.Difficulties = []string{}
+	}
+	for _, cand := range slices.Concat(reviews, weak, fresh) {
+		m := conceptMinutes[cand.c.Difficulty]
+		if cand.reason != reasonNew {
+			m = (m + 1) / 2
+		}
+		// The first item always fits, so a short budget still gets one.
+		if len(s.Items) > 0 && s.PlannedMinutes+m > minutes {
+			continue
+		}
+		s.PlannedMinutes += m
+		s.Items = append(s.Items, sessionItem{
+			ID:         cand.c.ID,
+			Number:     cand.c.Number,
+			Name:       cand.c.Name,
+			Category:   cand.c.Category,
+			Difficulty: cand.c.Difficulty,
+			Reason:     cand.reason,
+			Note:       cand.note,
+			Minutes:    m,
+		})
+	}
+	return s
+}
@@ -44,7 +44,16 @@ let learnedConcepts = {};
 let settings = { defaultExpiryDays: 14 };
 let activeDifficulties = new Set(['beginner']); // Start with beginner only
 let searchQuery = ''; // Search filter
-let usedAssistance = false; // Track if user used (?) or Show Answer for current concept
+let searchMatches = null; // Set of concept IDs from /api/search, null = substring fallback
+let searchTimeout = null;
+let usedAssistance = false; // Track if user opened the (?) explanation for current concept
+let attemptTries = 0; // Runs or checks of the current concept since it was loaded or learned
+let answerRevealed = false; // Show Answer was used in the current attempt
+let editLog = null; // Editor changes in the current attempt; see beginEditLog
+let hintState = null; // Hints revealed for currentConcept, as returned by /api/hints
+let exam = null; // The exam in progress, as returned by /api/exam
+let examDeadline = 0; // When it ends, by this browser's clock
+let examTimer = null;
 
 // Category order (Core Syntax first, then by importance)
 const CATEGORY_ORDER = [
@@ -73,7 +82,15 @@ function createWorker() {
         wasmWorker.terminate();
     }
     wasmReady = false;
-    wasmWorker = new Worker('/static/worker.js');
+    // Asset URLs carry content hashes from the server template; the worker
+    // receives its dependencies' URLs through its own query string.
+    const ds = document.body.dataset;
+    const params = new URLSearchParams({
+        exec: ds.wasmExec || '/static/wasm_exec.js',
+        wasm: ds.wasm || '/static/yaegi.wasm'
+    });
+    const workerUrl = (ds.worker || '/static/worker.js');
+    wasmWorker = new Worker(workerUrl + (workerUrl.includes('?') ? '&' : '?') + params);
     wasmWorker.onmessage = function (e) {
         if (e.data.type === 'ready') {
             wasmReady = true;
@@ -153,16 +170,29 @@ document.addEventListener('DOMContentLoaded', async () => {
     loadSettings();
     loadLearnedConcepts();
     await fetchConcepts();
+    await checkLearnedVersions();
     initEditor();
     renderConcepts();
+    loadToday();
+    loadMastery();
     startExpiryCheck();
     setupEventListeners();
 
     // Start WASM worker
     createWorker();
 
-    // Show possum credit on initial load
-    document.getElementById('possum-credit').style.display = 'block';
+    // An exam survives reloads: its clock runs on the server
+    if (await resumeExam()) return;
+
+    // Deep link from a server-rendered concept page (/?concept=<id>)
+    const linked = conceptFromURL();
+    if (linked) {
+        history.replaceState(null, '', conceptPath(linked));
+        loadConcept(linked);
+    } else {
+        // Show possum credit on initial load
+        document.getElementById('possum-credit').style.display = 'block';
+    }
 });
 
 function loadSettings() {
@@ -204,6 +234,12 @@ function saveDraft(conceptId, code) {
     const drafts = JSON.parse(localStorage.getItem('drafts') || '{}');
     drafts[conceptId] = code;
     localStorage.setItem('drafts', JSON.stringify(drafts));
+    // The log travels with the draft, so a reload resumes it
+    if (editLog && editLog.id === conceptId) {
+        const logs = JSON.parse(localStorage.getItem('editLogs') || '{}');
+        logs[conceptId] = editLog;
+        localStorage.setItem('editLogs', JSON.stringify(logs));
+    }
 }
 
 function getDraft(conceptId) {
@@ -215,6 +251,44 @@ function clearDraft(conceptId) {
     const drafts = JSON.parse(localStorage.getItem('drafts') || '{}');
     delete drafts[conceptId];
     localStorage.setItem('drafts', JSON.stringify(drafts));
+    const logs = JSON.parse(localStorage.getItem('editLogs') || '{}');
+    delete logs[conceptId];
+    localStorage.setItem('editLogs', JSON.stringify(logs));
+}
+
+// The editor log records every change with its time and kind, so the server
+// can tell typed solutions from pasted ones and play them back. A log starts
+// from the text loaded into the editor; with a draft it resumes the log saved
+// alongside it.
+const EDIT_OPS = {
+    '+input': 'insert', '*compose': 'insert',
+    paste: 'paste', drop: 'paste',
+    '+delete': 'delete', cut: 'delete',
+    undo: 'undo', redo: 'undo',
+    setValue: 'set'
+};
+const MAX_EDIT_EVENTS = 20000;
+
+function beginEditLog(conceptId, text, resume) {
+    const stored = resume && JSON.parse(localStorage.getItem('editLogs') || '{}')[conceptId];
+    editLog = stored || { id: conceptId, start: text, startedAt: Date.now(), events: [] };
+}
+
+function recordEdit(change) {
+    if (!editLog || !currentConcept || currentConcept.exam || editLog.events.length >= MAX_EDIT_EVENTS) return;
+    const text = change.text.join('\n');
+    const removed = change.removed.join('\n').length;
+    let op = EDIT_OPS[change.origin] || 'insert';
+    if (op === 'insert' && !text) op = 'delete';
+    const last = editLog.events[editLog.events.length - 1];
+    editLog.events.push({
+        t: Math.max(last ? last.t : 0, Date.now() - editLog.startedAt),
+        op: op,
+        from: [change.from.line, change.from.ch],
+        to: [change.to.line, change.to.ch],
+        text: text || undefined,
+        removed: removed || undefined
+    });
 }
 
 async function fetchConcepts() {
@@ -222,6 +296,39 @@ async function fetchConcepts() {
     concepts = await response.json();
 }
 
+// Flag learned concepts whose instruction or expected output changed since
+// they were learned. Stale concepts go back into the practice queue until the
+// learner passes them again. Records from before versioning adopt the
+// current version.
+async function checkLearnedVersions() {
+    const learned = {};
+    Object.keys(learnedConcepts).forEach(id => {
+        const concept = concepts.find(c => c.id === id);
+        if (!learnedConcepts[id].version && concept) {
+            learnedConcepts[id].version = concept.version;
+        }
+        learned[id] = learnedConcepts[id].version || '';
+    });
+    saveLearnedConcepts();
+    if (Object.keys(learned).length === 0) return;
+
+    try {
+        const response = await fetch('/api/progress/check', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ learned })
+        });
+        if (!response.ok) return;
+        const result = await response.json();
+        result.stale.forEach(s => {
+            learnedConcepts[s.id].stale = true;
+        });
+        saveLearnedConcepts();
+    } catch (err) {
+        console.error('Progress version check failed:', err);
+    }
+}
+
 function initEditor() {
     const textarea = document.getElementById('code-editor');
     editor = CodeMirror.fromTextArea(textarea, {
@@ -272,10 +379,16 @@ l   .-~~"-.    /    I
                                  ~~`;
     editor.setValue(possumArt);
 
+    editor.on('change', (cm, change) => recordEdit(change));
+
     // Auto-save draft as user types (debounced)
     let saveTimeout;
     editor.on('change', () => {
         if (!currentConcept) return;
+        if (currentConcept.exam) {
+            sessionStorage.setItem('exam-draft:' + currentConcept.id, editor.getValue());
+            return;
+        }
         clearTimeout(saveTimeout);
         saveTimeout = setTimeout(() => {
             const code = editor.getValue();
@@ -300,10 +413,12 @@ function renderUnlearned() {
     concepts.forEach(c => {
         // Filter by active difficulties, learned status, and search query
         const matchesSearch = searchQuery === '' ||
-            c.name.toLowerCase().includes(searchQuery.toLowerCase()) ||
-            c.description.toLowerCase().includes(searchQuery.toLowerCase());
+            (searchMatches ? searchMatches.has(c.id) :
+                c.name.toLowerCase().includes(searchQuery.toLowerCase()) ||
+                c.description.toLowerCase().includes(searchQuery.toLowerCase()));
 
-        if (!learnedConcepts[c.id] && activeDifficulties.has(c.difficulty) && matchesSearch) {
+        const needsPractice = !learnedConcepts[c.id] || learnedConcepts[c.id].stale;
+        if (needsPractice && activeDifficulties.has(c.difficulty) && matchesSearch) {
             if (!grouped[c.category]) grouped[c.category] = [];
             grouped[c.category].push(c);
         }
@@ -384,9 +499,32 @@ function createConceptCard(concept) {
     return card;
 }
 
-function loadConcept(concept) {
+// The address bar follows the open concept with its shareable page,
+// /concepts/<id>, which is readable without JavaScript.
+function conceptPath(concept) {
+    return '/concepts/' + encodeURIComponent(concept.id);
+}
+
+function conceptFromURL() {
+    const match = location.pathname.match(/^\/concepts\/([^/]+)$/);
+    const id = match ? decodeURIComponent(match[1]) : new URLSearchParams(location.search).get('concept');
+    return concepts.find(c => c.id === id);
+}
+
+function loadConcept(concept, fromHistory) {
+    if (exam) {
+        // Practice views could show stored solutions; stay in the exam
+        const ec = exam.concepts.find(e => e.id === concept.id);
+        if (ec) loadExamConcept(ec);
+        return;
+    }
     currentConcept = concept;
+    if (!fromHistory && location.pathname !== conceptPath(concept)) {
+        history.pushState(null, '', conceptPath(concept));
+    }
     usedAssistance = false; // Reset assistance flag for new concept
+    attemptTries = 0;
+    answerRevealed = false;
     document.getElementById('concept-title').textContent = concept.name;
     document.getElementById('concept-instruction').textContent = concept.instruction;
 
@@ -401,22 +539,32 @@ function loadConcept(concept) {
 
     let codeToLoad = concept.boilerplate;
     let outputMessage = '';
+    const isPredict = concept.kind === 'predict';
 
-    if (draft) {
+    if (isPredict) {
+        // The program is the question: show it as-is and read-only
+        outputMessage = isLearned ? '✓ Learned. Predict it again any time.' : '';
+    } else if (draft) {
         codeToLoad = draft;
         outputMessage = '💾 Draft loaded (auto-saved)';
-    } else if (solution) {
+    } else if (solution && !concept.variants) {
+        // A parameterised concept's solution was for an earlier variant
         codeToLoad = solution;
         outputMessage = '✓ Your solution (click Run to validate again)';
+    } else if (concept.kind === 'fix') {
+        outputMessage = `🐞 This program has a bug. Fix it by changing at most ${concept.maxLinesChanged} lines.`;
     }
 
+    editLog = null;
     editor.setValue(codeToLoad);
+    beginEditLog(concept.id, codeToLoad, !!draft && !isPredict);
+    setExerciseKind(isPredict);
 
     // Set output message if any
     if (outputMessage) {
         const outputEl = document.getElementById('output-content');
         outputEl.textContent = outputMessage;
-        outputEl.className = solution ? 'success' : '';
+        outputEl.className = solution || isPredict ? 'success' : '';
     } else {
         clearOutput();
     }
@@ -425,12 +573,405 @@ function loadConcept(concept) {
     document.getElementById('teach-btn').style.display = 'block';
 
     // Show/hide tests button based on whether concept has test cases
-    const hasTests = concept.testCases && concept.testCases.length > 0;
+    const hasTests = !isPredict && concept.testCases && concept.testCases.length > 0;
     document.getElementById('show-tests-btn').style.display = hasTests ? 'block' : 'none';
 
     // Show/hide answer button based on whether concept has an answer
     const hasAnswer = concept.answer && concept.answer.length > 0;
     document.getElementById('show-answer-btn').style.display = hasAnswer ? 'block' : 'none';
+
+    renderQuiz(concept);
+    if (concept.variants) loadVariant(concept);
+
+    // Restore hints already revealed in this attempt
+    hintState = null;
+    renderHints();
+    loadHints(concept.id);
+}
+
+// Exams are drawn, timed and graded by the server. While one runs, exam
+// mode hides hints, answers and tests, and Submit replaces Run.
+async function resumeExam() {
+    try {
+        const response = await fetch('/api/exam');
+        if (!response.ok) return false;
+        const state = await response.json();
+        if (!state.active) return false;
+        enterExam(state);
+        return true;
+    } catch (err) {
+        return false;
+    }
+}
+
+function openExamModal() {
+    if (exam) return;
+    document.getElementById('exam-setup').style.display = 'block';
+    document.getElementById('exam-report').style.display = 'none';
+    document.getElementById('exam-modal').style.display = 'block';
+}
+
+function closeExamModal() {
+    document.getElementById('exam-modal').style.display = 'none';
+}
+
+async function startExam() {
+    const count = parseInt(document.getElementById('exam-count').value, 10);
+    const minutes = parseInt(document.getElementById('exam-minutes').value, 10);
+    const response = await fetch('/api/exam', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ count: count, minutes: minutes, difficulties: [...activeDifficulties] })
+    });
+    const result = await response.json();
+    if (response.status === 409) {
+        enterExam(result.exam);
+    } else if (!response.ok) {
+        alert('Could not start the exam: ' + result.error);
+        return;
+    } else {
+        enterExam(result);
+    }
+    closeExamModal();
+}
+
+function enterExam(state) {
+    exam = state;
+    examDeadline = Date.now() + state.remaining * 1000;
+    document.body.classList.add('exam-mode');
+    document.getElementById('exam-bar').style.display = 'flex';
+    clearInterval(examTimer);
+    examTimer = setInterval(tickExam, 1000);
+    tickExam();
+    loadExamConcept(exam.concepts[0]);
+}
+
+function formatSeconds(s) {
+    return `${Math.floor(s / 60)}:${String(s % 60).padStart(2, '0')}`;
+}
+
+function tickExam() {
+    const left = Math.max(0, Math.round((examDeadline - Date.now()) / 1000));
+    const timer = document.getElementById('exam-timer');
+    timer.textContent = `\u23f1 ${formatSeconds(left)}`;
+    timer.classList.toggle('low', left < 60);
+    if (left === 0) endExam();
+}
+
+function renderExamBar() {
+    const bar = document.getElementById('exam-concepts');
+    bar.innerHTML = '';
+    exam.concepts.forEach((ec, i) => {
+        const btn = document.createElement('button');
+        btn.textContent = i + 1;
+        btn.title = ec.name;
+        if (exam.submissions.some(s => s.concept === ec.id && s.correct)) btn.classList.add('solved');
+        if (currentConcept && currentConcept.id === ec.id) btn.classList.add('current');
+        btn.addEventListener('click', () => loadExamConcept(ec));
+        bar.appendChild(btn);
+    });
+}
+
+function loadExamConcept(ec) {
+    currentConcept = { ...ec, exam: true };
+    document.getElementById('concept-title').textContent = ec.name;
+    document.getElementById('concept-instruction').textContent = ec.instruction;
+    document.getElementById('possum-credit').style.display = 'none';
+    editLog = null;
+    editor.setValue(sessionStorage.getItem('exam-draft:' + ec.id) || ec.boilerplate);
+    setExerciseKind(ec.kind === 'predict');
+    const outputEl = document.getElementById('output-content');
+    outputEl.textContent = ec.kind === 'fix'
+        ? `\ud83d\udcdd Exam: fix the bug by changing at most ${ec.maxLinesChanged} lines, then Submit.`
+        : '\ud83d\udcdd Exam: Submit sends your answer for grading.';
+    outputEl.className = '';
+    renderExamBar();
+}
+
+async function submitExam() {
+    if (!exam || !currentConcept || !currentConcept.exam) return;
+    const id = currentConcept.id;
+    const payload = { concept: id };
+    if (currentConcept.kind === 'predict') {
+        payload.output = document.getElementById('predict-input').value;
+    } else {
+        payload.code = editor.getValue();
+    }
+    const outputEl = document.getElementById('output-content');
+    const submitBtn = document.getElementById('exam-submit-btn');
+    submitBtn.disabled = true;
+    outputEl.textContent = 'Grading...';
+    outputEl.className = '';
+    try {
+        const response = await fetch('/api/exam/submit', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(payload)
+        });
+        const result = await response.json();
+        if (response.status === 409) {
+            endExam();
+            return;
+        }
+        if (!response.ok) {
+            outputEl.textContent = `Error: ${result.error}`;
+            outputEl.className = 'error';
+            return;
+        }
+        exam.submissions.push({ concept: id, at: new Date().toISOString(), correct: result.correct });
+        let msg = result.correct ? '\u2713 Correct' : '\u2717 Not correct';
+        if (result.linesChanged) msg += ` (${result.linesChanged} lines changed)`;
+        if (result.runError) msg += `\n\nError: ${result.runError}`;
+        if (result.output) msg += `\n\nOutput:\n${result.output}`;
+        outputEl.textContent = msg;
+        outputEl.className = result.correct ? 'success' : 'error';
+        renderExamBar();
+    } catch (err) {
+        outputEl.textContent = `Error: ${err.message}`;
+        outputEl.className = 'error';
+    } finally {
+        submitBtn.disabled = false;
+    }
+}
+
+async function finishExam() {
+    if (!confirm('Finish the exam now? You cannot submit again afterwards.')) return;
+    const response = await fetch('/api/exam/finish', { method: 'POST' });
+    if (response.ok) showExamReport(await response.json());
+}
+
+async function endExam() {
+    if (!exam) return;
+    clearInterval(examTimer);
+    const response = await fetch('/api/exam');
+    if (response.ok) showExamReport(await response.json());
+}
+
+function showExamReport(state) {
+    clearInterval(examTimer);
+    exam.concepts.forEach(ec => sessionStorage.removeItem('exam-draft:' + ec.id));
+    exam = null;
+    document.body.classList.remove('exam-mode');
+    document.getElementById('exam-bar').style.display = 'none';
+
+    const report = state.report;
+    document.getElementById('exam-score').textContent =
+        `Score: ${report.score} / ${report.total} in ${formatSeconds(report.duration)}`;
+    const list = document.getElementById('exam-results');
+    list.innerHTML = '';
+    report.results.forEach(res => {
+        const item = document.createElement('li');
+        item.className = res.solved ? 'solved' : 'unsolved';
+        const tries = `${res.attempts} submission${res.attempts === 1 ? '' : 's'}`;
+        item.textContent = res.solved
+            ? `${res.name}: solved at ${formatSeconds(res.solvedAfter || 0)}, ${tries}`
+            : `${res.name}: not solved, ${tries}`;
+        list.appendChild(item);
+    });
+    document.getElementById('exam-setup').style.display = 'none';
+    document.getElementById('exam-report').style.display = 'block';
+    document.getElementById('exam-modal').style.display = 'block';
+
+    // Back to practice on the last exam concept, answers and all
+    const last = currentConcept && concepts.find(c => c.id === currentConcept.id);
+    if (last) loadConcept(last);
+}
+
+// Parameterised concepts get fresh values for each attempt. The variant
+// replaces currentConcept, so runs are checked against its expected output.
+async function loadVariant(concept) {
+    let variant;
+    try {
+        const response = await fetch(`/api/variants/${encodeURIComponent(concept.id)}`);
+        if (!response.ok) return;
+        variant = await response.json();
+    } catch (err) {
+        return; // Offline: keep the default variant
+    }
+    if (currentConcept !== concept) return;
+    currentConcept = variant;
+    document.getElementById('concept-instruction').textContent = variant.instruction;
+    if (editor.getValue() === concept.boilerplate) {
+        editLog = null;
+        editor.setValue(variant.boilerplate);
+        beginEditLog(variant.id, variant.boilerplate, false);
+    }
+}
+
+// Quiz questions are graded by the server, which keeps the answers. A miss
+// shortens the next review interval, and brings the review of a learned
+// concept forward.
+function renderQuiz(concept) {
+    const quiz = document.getElementById('quiz');
+    const list = document.getElementById('quiz-list');
+    list.innerHTML = '';
+    quiz.style.display = concept.quiz && concept.quiz.length > 0 ? 'block' : 'none';
+    (concept.quiz || []).forEach((question, qi) => {
+        const item = document.createElement('li');
+        const prompt = document.createElement('div');
+        prompt.textContent = question.prompt;
+        item.appendChild(prompt);
+        const choices = document.createElement('div');
+        choices.className = 'quiz-choices';
+        question.choices.forEach((choice, ci) => {
+            const btn = document.createElement('button');
+            btn.className = 'quiz-choice';
+            btn.textContent = choice;
+            btn.addEventListener('click', () => answerQuiz(concept.id, qi, ci, item));
+            choices.appendChild(btn);
+        });
+        item.appendChild(choices);
+        list.appendChild(item);
+    });
+}
+
+async function answerQuiz(id, question, choice, item) {
+    const buttons = item.querySelectorAll('.quiz-choice');
+    buttons.forEach(btn => { btn.disabled = true; });
+    let result;
+    try {
+        const response = await fetch(`/api/quiz/${encodeURIComponent(id)}/answer`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ question: question, choice: choice })
+        });
+        if (!response.ok) throw new Error(response.statusText);
+        result = await response.json();
+    } catch (err) {
+        buttons.forEach(btn => { btn.disabled = false; });
+        return;
+    }
+
+    buttons[result.answer].classList.add('correct');
+    if (!result.correct) buttons[choice].classList.add('wrong');
+    const rationale = document.createElement('p');
+    rationale.className = 'quiz-rationale';
+    rationale.textContent = (result.correct ? '\u2713 ' : '\u2717 ') + result.rationale;
+    item.appendChild(rationale);
+
+    if (result.learned && learnedConcepts[id]) {
+        learnedConcepts[id] = learnedRecord(result.learned);
+        saveLearnedConcepts();
+        checkExpiry();
+        renderConcepts();
+    }
+}
+
+// Predict-the-output concepts show a read-only program and ask for its
+// output instead of running code.
+function setExerciseKind(isPredict) {
+    editor.setOption('readOnly', isPredict);
+    document.getElementById('predict-panel').style.display = isPredict ? 'flex' : 'none';
+    document.getElementById('predict-input').value = '';
+    document.getElementById('predict-check-btn').style.display = isPredict ? 'block' : 'none';
+    document.getElementById('run-btn').style.display = isPredict ? 'none' : 'block';
+    document.getElementById('reset-btn').style.display = isPredict ? 'none' : 'block';
+}
+
+async function checkPrediction() {
+    if (!currentConcept) return;
+    const outputEl = document.getElementById('output-content');
+    const checkBtn = document.getElementById('predict-check-btn');
+    checkBtn.disabled = true;
+    try {
+        const response = await fetch(`/api/grade/${encodeURIComponent(currentConcept.id)}`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ output: document.getElementById('predict-input').value, seed: currentConcept.seed || 0 })
+        });
+        const result = await response.json();
+        if (!response.ok) {
+            outputEl.textContent = `Error: ${result.error}`;
+            outputEl.className = 'error';
+            return;
+        }
+        attemptTries++;
+        if (result.correct) {
+            outputEl.textContent = '\u2713 Correct! That is exactly what it prints.';
+            outputEl.className = 'success';
+            markAsLearned(currentConcept.id, { output: document.getElementById('predict-input').value });
+        } else {
+            outputEl.textContent = `\u2717 Not quite: the first ${result.matchingLines} of ${result.expectedLines} lines are right.`;
+            outputEl.className = 'error';
+        }
+    } catch (err) {
+        outputEl.textContent = `Error: ${err.message}`;
+        outputEl.className = 'error';
+    } finally {
+        checkBtn.disabled = false;
+    }
+}
+
+// Hints are revealed one at a time by the server, which records how far the
+// learner went so the review interval can be shortened accordingly.
+async function loadHints(id) {
+    try {
+        const response = await fetch(`/api/hints/${encodeURIComponent(id)}`);
+        if (!response.ok) return;
+        const state = await response.json();
+        if (currentConcept && currentConcept.id === id) {
+            hintState = state;
+            renderHints();
+        }
+    } catch (err) {
+        // Hints stay hidden if the server cannot be reached
+    }
+}
+
+async function revealHints(level) {
+    const id = currentConcept.id;
+    const query = level ? `?level=${level}` : '';
+    const response = await fetch(`/api/hints/${encodeURIComponent(id)}/reveal${query}`, { method: 'POST' });
+    if (!response.ok) throw new Error('could not reveal hint');
+    const state = await response.json();
+    if (currentConcept && currentConcept.id === id) {
+        hintState = state;
+        renderHints();
+    }
+    return state;
+}
+
+const HINT_LABELS = { nudge: 'Nudge', api: 'API', skeleton: 'Skeleton', location: 'Location', answer: 'Answer' };
+
+function renderHints() {
+    const list = document.getElementById('hints-list');
+    const hintBtn = document.getElementById('hint-btn');
+    list.innerHTML = '';
+    if (!hintState) {
+        hintBtn.style.display = 'none';
+        return;
+    }
+
+    // The last hint is the answer, which Show Answer loads into the editor
+    hintState.hints.filter(h => h.kind !== 'answer').forEach(hint => {
+        const item = document.createElement('li');
+        const kind = document.createElement('span');
+        kind.className = 'hint-kind';
+        kind.textContent = HINT_LABELS[hint.kind] || 'Hint';
+        item.appendChild(kind);
+        if (hint.kind === 'skeleton') {
+            const pre = document.createElement('pre');
+            pre.textContent = hint.text;
+            item.appendChild(pre);
+        } else {
+            item.appendChild(document.createTextNode(hint.text));
+        }
+        list.appendChild(item);
+    });
+
+    const remaining = hintState.levels - 1 - hintState.level;
+    hintBtn.style.display = 'block';
+    hintBtn.disabled = remaining <= 0;
+    hintBtn.textContent = remaining > 0 ? `Hint (${hintState.level + 1}/${hintState.levels - 1})` : 'No more hints';
+}
+
+async function showHint() {
+    if (!currentConcept) return;
+    try {
+        await revealHints();
+    } catch (err) {
+        alert('Could not load a hint. Is the server running?');
+    }
 }
 
 function renderLearned() {
@@ -456,7 +997,9 @@ function renderLearned() {
 
         const timer = document.createElement('div');
         timer.className = 'learned-timer';
-        timer.textContent = getTimeRemaining(id);
+        timer.textContent = conceptData.stale ?
+            '⚠ Concept changed, solve it again' :
+            getTimeRemaining(id);
 
         contentDiv.appendChild(name);
         contentDiv.appendChild(timer);
@@ -508,6 +1051,19 @@ function renderLearned() {
             });
         }
 
+        // Replay button for code exercises: plays back how it was written
+        if (concept.kind !== 'predict') {
+            const replayBtn = document.createElement('button');
+            replayBtn.className = 'replay-btn';
+            replayBtn.innerHTML = '▶';
+            replayBtn.title = conceptData.pasted ? 'Replay your solution (mostly pasted)' : 'Replay your solution';
+            replayBtn.addEventListener('click', (e) => {
+                e.stopPropagation();
+                openReplay(concept);
+            });
+            card.appendChild(replayBtn);
+        }
+
         card.appendChild(trashBtn);
 
         learnedList.appendChild(card);
@@ -559,10 +1115,27 @@ function setupEventListeners() {
     document.getElementById('settings-btn').addEventListener('click', openSettings);
     document.querySelector('.close').addEventListener('click', closeSettings);
     document.getElementById('save-settings').addEventListener('click', saveSettingsModal);
+    document.getElementById('export-progress').addEventListener('click', exportProgress);
+    document.getElementById('import-progress').addEventListener('change', importProgress);
     document.getElementById('teach-btn').addEventListener('click', openTeachingPanel);
     document.querySelector('.close-teaching').addEventListener('click', closeTeachingPanel);
     document.getElementById('show-tests-btn').addEventListener('click', showTests);
     document.getElementById('show-answer-btn').addEventListener('click', showAnswer);
+    document.getElementById('hint-btn').addEventListener('click', showHint);
+    document.getElementById('predict-check-btn').addEventListener('click', checkPrediction);
+    document.getElementById('exam-btn').addEventListener('click', openExamModal);
+    document.querySelector('.close-exam').addEventListener('click', closeExamModal);
+    document.getElementById('exam-start-btn').addEventListener('click', startExam);
+    document.getElementById('exam-submit-btn').addEventListener('click', submitExam);
+    document.getElementById('exam-finish-btn').addEventListener('click', finishExam);
+    document.querySelector('.close-replay').addEventListener('click', closeReplay);
+
+    // Back and forward move between the concepts opened in this tab
+    window.addEventListener('popstate', () => {
+        const concept = conceptFromURL();
+        if (concept) loadConcept(concept, true);
+    });
+    document.getElementById('replay-play-btn').addEventListener('click', playReplay);
 
     // Difficulty filter buttons
     document.querySelectorAll('.filter-btn').forEach(btn => {
@@ -582,6 +1155,8 @@ function setupEventListeners() {
             }
 
             renderConcepts();
+            loadToday();
+            loadMastery();
         });
     });
 
@@ -605,10 +1180,30 @@ function setupEventListeners() {
     // Search input
     document.getElementById('search-input').addEventListener('input', (e) => {
         searchQuery = e.target.value.trim();
+        searchMatches = null;
         renderConcepts();
+        clearTimeout(searchTimeout);
+        if (searchQuery !== '') {
+            searchTimeout = setTimeout(() => runSearch(searchQuery), 200);
+        }
     });
 }
 
+// Full-text search on the server (explanations, examples, identifiers).
+// Falls back to the local name/description filter if the request fails.
+async function runSearch(query) {
+    try {
+        const response = await fetch(`/api/search?limit=100&q=${encodeURIComponent(query)}`);
+        if (!response.ok) return;
+        const data = await response.json();
+        if (query !== searchQuery) return; // stale response
+        searchMatches = new Set(data.results.map(r => r.id));
+        renderConcepts();
+    } catch (err) {
+        console.error('Search failed:', err);
+    }
+}
+
 function navigateToConcept(targetNumber) {
     // Find concept by number
     const targetConcept = concepts.find(c => c.number === targetNumber);
@@ -691,6 +1286,7 @@ function openTeachingPanel() {
         docsLink.href = 'https://go.dev/doc/';
         docsLink.textContent = 'General Go Documentation →';
     }
+    document.getElementById('teaching-page-link').href = '/concepts/' + encodeURIComponent(currentConcept.id);
 
     document.getElementById('teaching-modal').style.display = 'flex';
 }
@@ -721,33 +1317,50 @@ async function runCode() {
     const runStart = Date.now();
     try {
         const result = await executeInWorker(code);
+        attemptTries++;
         const runDurationMs = Date.now() - runStart;
 
+        const outputStr = (result.output || '').trim();
+        const expected = (currentConcept.expectedOutput || '').trim();
+        let success = !result.error && outputStr === expected;
+
+        // A fix must also stay close to the starting program
+        let fix = null;
+        if (success && currentConcept.kind === 'fix') {
+            fix = await gradeFix(code);
+            if (fix) success = fix.correct;
+        }
+
         fetch('/api/log-run', {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({
                 exit_code: result.error ? 1 : 0,
                 duration_ms: runDurationMs,
-                output_bytes: (result.output || '').length + (result.error || '').length
+                output_bytes: (result.output || '').length + (result.error || '').length,
+                concept: currentConcept.id,
+                outcome: success ? 'pass' : result.error ? 'error' : 'fail',
+                revealed: answerRevealed
             })
         }).catch(() => {});
 
-        const outputStr = (result.output || '').trim();
-        const expected = (currentConcept.expectedOutput || '').trim();
-        const success = !result.error && outputStr === expected;
-
         if (success) {
-            outputEl.textContent = `\u2713 Success!\n\nOutput:\n${outputStr}`;
+            const changed = fix ? ` You fixed it by changing ${fix.linesChanged} line${fix.linesChanged === 1 ? '' : 's'}.` : '';
+            outputEl.textContent = `\u2713 Success!${changed}\n\nOutput:\n${outputStr}`;
             outputEl.className = 'success';
             saveSolution(currentConcept.id, code);
             clearDraft(currentConcept.id);
-            markAsLearned(currentConcept.id);
+            markAsLearned(currentConcept.id, { code: code });
         } else {
             let msg = '\u2717 Failed\n\n';
             if (result.error) {
                 msg += `Error: ${result.error}\n\n`;
             }
+            if (fix && !fix.correct) {
+                msg += fix.linesChanged
+                    ? `The output is right, but you changed ${fix.linesChanged} lines. Fix the bug by changing at most ${fix.maxLinesChanged}.\n\n`
+                    : 'The output is right, but you have not changed anything. Find the bug and fix it.\n\n';
+            }
             if (outputStr && outputStr !== expected) {
                 msg += `Expected: ${JSON.stringify(expected)}\nGot: ${JSON.stringify(outputStr)}\n\n`;
             }
@@ -766,22 +1379,203 @@ async function runCode() {
     }
 }
 
-function markAsLearned(id) {
-    // Calculate expiry days - halve if user needed assistance
-    const expiryDays = usedAssistance ?
-        Math.max(1, Math.floor(settings.defaultExpiryDays / 2)) :
-        settings.defaultExpiryDays;
+// gradeFix asks the server to check a fix-the-bug submission, which also
+// counts the lines changed. Returns null if the server cannot grade, in
+// which case the local run decides, as it does offline.
+async function gradeFix(code) {
+    try {
+        const response = await fetch(`/api/grade/${encodeURIComponent(currentConcept.id)}`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ code: code, seed: currentConcept.seed || 0 })
+        });
+        if (!response.ok) return null;
+        return await response.json();
+    } catch (err) {
+        return null;
+    }
+}
+
+// Today's session: reviews due, weak spots and the next new concepts that fit
+// the learner's daily budget. The server keeps the plan for the day and ticks
+// items off as they are marked learned.
+async function loadToday() {
+    const params = new URLSearchParams({
+        difficulty: [...activeDifficulties].join(','),
+        tz: -new Date().getTimezoneOffset()
+    });
+    if (settings.sessionMinutes) params.set('minutes', settings.sessionMinutes);
+    try {
+        const response = await fetch('/api/session/today?' + params);
+        if (!response.ok) throw new Error(response.statusText);
+        renderToday(await response.json());
+    } catch (err) {
+        console.error('Could not load today\'s session:', err);
+        document.getElementById('today').style.display = 'none';
+    }
+}
+
+function renderToday(session) {
+    const list = document.getElementById('today-list');
+    list.innerHTML = '';
+    session.items.forEach(item => {
+        const li = document.createElement('li');
+        li.textContent = item.name;
+        if (item.done) li.classList.add('done');
+        const note = document.createElement('span');
+        note.className = 'today-note';
+        note.textContent = `${item.note} · ${item.minutes} min`;
+        li.appendChild(note);
+        li.addEventListener('click', () => {
+            const concept = concepts.find(c => c.id === item.id);
+            if (concept) loadConcept(concept);
+        });
+        list.appendChild(li);
+    });
+    document.getElementById('today-progress').textContent = session.complete ?
+        '— all done!' :
+        `(${session.completed}/${session.items.length}, ~${session.plannedMinutes} min)`;
+    document.getElementById('today').style.display = session.items.length ? 'block' : 'none';
+}
+
+// The server's mastery model estimates each category from the learner's
+// attempt history and recommends what to practise next.
+const NEXT_REASONS = {
+    review: 'due for review',
+    new: 'ready to learn',
+    strengthen: 'shaky, practise again'
+};
+
+async function loadMastery() {
+    const params = new URLSearchParams({ difficulty: [...activeDifficulties].join(',') });
+    try {
+        const response = await fetch('/api/mastery?' + params);
+        if (!response.ok) throw new Error(response.statusText);
+        renderMastery(await response.json());
+    } catch (err) {
+        console.error('Could not load mastery:', err);
+        document.getElementById('mastery').style.display = 'none';
+    }
+}
+
+function renderMastery(report) {
+    const nextUp = document.getElementById('next-up');
+    nextUp.innerHTML = '';
+    report.next.forEach(rec => {
+        const concept = concepts.find(c => c.id === rec.id);
+        if (!concept) return;
+        const li = document.createElement('li');
+        li.textContent = concept.name;
+        const reason = document.createElement('span');
+        reason.className = 'next-reason';
+        reason.textContent = NEXT_REASONS[rec.reason] || rec.reason;
+        li.appendChild(reason);
+        li.addEventListener('click', () => loadConcept(concept));
+        nextUp.appendChild(li);
+    });
+
+    const bars = document.getElementById('mastery-bars');
+    bars.innerHTML = '';
+    report.categories.forEach(cat => {
+        const row = document.createElement('div');
+        row.className = 'mastery-row';
+        row.title = `${cat.mastered} of ${cat.concepts} concepts mastered`;
+        const label = document.createElement('div');
+        label.className = 'mastery-label';
+        const name = document.createElement('span');
+        name.textContent = cat.name;
+        const pct = document.createElement('span');
+        pct.textContent = Math.round(cat.mastery * 100) + '%';
+        label.append(name, pct);
+        const track = document.createElement('div');
+        track.className = 'mastery-track';
+        const fill = document.createElement('div');
+        fill.className = 'mastery-fill';
+        fill.style.width = (cat.mastery * 100).toFixed(1) + '%';
+        track.appendChild(fill);
+        row.append(label, track);
+        bars.appendChild(row);
+    });
+    document.getElementById('mastery').style.display = 'block';
+}
 
-    learnedConcepts[id] = {
-        learnedAt: Date.now(),
-        expiryDays: expiryDays,
-        assisted: usedAssistance // Track if this concept was learned with assistance
+// learnedRecord converts a learned record from the server into the form
+// kept in localStorage.
+function learnedRecord(learned) {
+    return {
+        learnedAt: Date.parse(learned.learnedAt),
+        expiryDays: learned.expiryDays,
+        assisted: learned.assisted,
+        hintLevel: learned.hintLevel || 0,
+        quizMisses: learned.quizMisses || 0,
+        pasted: !!learned.pasted,
+        version: learned.version || ''
     };
+}
+
+// solution is what passed, { code } or { output }; the server grades it again.
+async function markAsLearned(id, solution) {
+    const concept = concepts.find(c => c.id === id);
+    const assisted = usedAssistance;
+    let record;
+    try {
+        // The server scales the review interval by the hints used
+        const response = await fetch(`/api/progress/${encodeURIComponent(id)}/learned`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({
+                expiryDays: settings.defaultExpiryDays,
+                assisted: assisted,
+                tries: attemptTries,
+                code: solution.code,
+                output: solution.output,
+                edits: editLog && editLog.id === id && concept && concept.kind !== 'predict'
+                    ? { start: editLog.start, events: editLog.events }
+                    : undefined
+            })
+        });
+        if (response.status === 422 || response.status === 409) {
+            // The server's grading disagrees: nothing is recorded
+            const result = await response.json();
+            const outputEl = document.getElementById('output-content');
+            outputEl.textContent += `\n\n\u26a0 Not marked as learned: ${result.error}.`;
+            return;
+        }
+        if (!response.ok) throw new Error(response.statusText);
+        const learned = await response.json();
+        record = learnedRecord(learned);
+        if (learned.pasted) {
+            const outputEl = document.getElementById('output-content');
+            outputEl.textContent += '\n\n\ud83d\udccb Most of this solution was pasted rather than typed, so it counts as assisted and comes back for review sooner.';
+        }
+    } catch (err) {
+        // Offline: halve the interval if any help was used
+        const helped = assisted || (hintState && hintState.level > 0);
+        record = {
+            learnedAt: Date.now(),
+            expiryDays: helped ? Math.max(1, Math.floor(settings.defaultExpiryDays / 2)) : settings.defaultExpiryDays,
+            assisted: !!helped,
+            hintLevel: hintState ? hintState.level : 0,
+            version: concept ? concept.version : '' // Content version this was earned against
+        };
+    }
+
+    learnedConcepts[id] = record;
+    if (currentConcept && currentConcept.id === id) {
+        hintState = hintState && { ...hintState, level: 0, hints: [] };
+        renderHints();
+    }
     saveLearnedConcepts();
     renderConcepts();
+    loadToday();
+    loadMastery();
 
     // Reset assistance flag after marking as learned
     usedAssistance = false;
+    attemptTries = 0;
+    answerRevealed = false;
+    // The next attempt starts from the passing code, which was not typed in it
+    if (currentConcept && currentConcept.id === id) beginEditLog(id, editor.getValue(), false);
 }
 
 function resetCode() {
@@ -854,25 +1648,113 @@ function showTests() {
     outputEl.className = '';
 }
 
-function showAnswer() {
+async function showAnswer() {
     if (!currentConcept || !currentConcept.answer) {
         return;
     }
 
-    // Mark that user used assistance for this concept (unless already learned)
-    if (!learnedConcepts[currentConcept.id]) {
+    // Revealing the answer is the last hint level; the server records it
+    answerRevealed = true;
+    try {
+        await revealHints(hintState ? hintState.levels : undefined);
+    } catch (err) {
         usedAssistance = true;
     }
 
+    const outputEl = document.getElementById('output-content');
+    if (currentConcept.kind === 'predict') {
+        // The answer is what the program prints, which only the server knows
+        const answer = hintState && hintState.hints.find(h => h.kind === 'answer');
+        outputEl.textContent = answer ? `💡 The program prints:\n${answer.text}` : '💡 The answer is not available right now.';
+        outputEl.className = '';
+        return;
+    }
+
     editor.setValue(currentConcept.answer);
 
-    const outputEl = document.getElementById('output-content');
     outputEl.textContent = '💡 Answer loaded. Click "Run Code" to test it.';
     outputEl.className = '';
 }
 
+// Replay plays back the editor log of the learner's latest pass of a
+// concept, at a multiple of the original pace with long pauses shortened.
+let replayEditor = null;
+let replayData = null;
+let replayRun = 0; // bumped to cancel a playback in progress
+
+async function openReplay(concept) {
+    let data;
+    try {
+        const response = await fetch('/api/replay/' + encodeURIComponent(concept.id));
+        if (response.status === 404) {
+            alert('No replay for this concept yet. Solve it again to record one.');
+            return;
+        }
+        if (!response.ok) throw new Error(response.statusText);
+        data = await response.json();
+    } catch (err) {
+        alert('Could not load the replay. Is the server running?');
+        return;
+    }
+    replayData = data;
+    replayRun++;
+
+    const sig = data.signals;
+    const parts = [
+        `${sig.typed} typed`,
+        `${sig.pasted + (sig.restored || 0) + (sig.unlogged || 0)} untyped (${Math.round(sig.pasteRatio * 100)}%)`,
+        `${sig.bursts || 0} bursts`,
+        `${Math.round(sig.durationMs / 1000)}s`
+    ];
+    document.getElementById('replay-title').textContent = concept.name;
+    document.getElementById('replay-stats').textContent = parts.join(' · ') +
+        (sig.largePaste ? ' · counted as assisted' : '');
+
+    document.getElementById('replay-modal').style.display = 'block';
+    if (!replayEditor) {
+        replayEditor = CodeMirror(document.getElementById('replay-editor'), {
+            mode: 'text/x-go',
+            theme: 'monokai',
+            lineNumbers: true,
+            indentUnit: 4,
+            indentWithTabs: true,
+            tabSize: 4,
+            readOnly: true
+        });
+    }
+    replayEditor.setValue(data.start);
+    replayEditor.refresh();
+}
+
+async function playReplay() {
+    if (!replayData) return;
+    const run = ++replayRun;
+    const speed = parseInt(document.getElementById('replay-speed').value) || 1;
+    replayEditor.setValue(replayData.start);
+    let last = replayData.events.length ? replayData.events[0].t : 0;
+    for (const e of replayData.events) {
+        const wait = Math.min(e.t - last, 1000) / speed;
+        last = e.t;
+        if (wait > 0) await new Promise(resolve => setTimeout(resolve, wait));
+        if (run !== replayRun) return;
+        if (e.op === 'set') {
+            replayEditor.setValue(e.text || '');
+        } else {
+            const from = { line: e.from[0], ch: e.from[1] };
+            const to = { line: e.to[0], ch: e.to[1] };
+            replayEditor.replaceRange(e.text || '', from, to);
+        }
+    }
+}
+
+function closeReplay() {
+    replayRun++;
+    document.getElementById('replay-modal').style.display = 'none';
+}
+
 function openSettings() {
     document.getElementById('expiry-days').value = settings.defaultExpiryDays;
+    document.getElementById('session-minutes').value = settings.sessionMinutes || 20;
     document.getElementById('settings-modal').style.display = 'block';
 }
 
@@ -882,11 +1764,89 @@ function closeSettings() {
 
 function saveSettingsModal() {
     const days = parseInt(document.getElementById('expiry-days').value);
-    if (days > 0 && days <= 365) {
+    const minutes = parseInt(document.getElementById('session-minutes').value);
+    if (!(days > 0 && days <= 365)) {
+        alert('Please enter a valid number between 1 and 365');
+    } else if (!(minutes >= 5 && minutes <= 240)) {
+        alert('Please enter a session length between 5 and 240 minutes');
+    } else {
         settings.defaultExpiryDays = days;
+        settings.sessionMinutes = minutes;
         saveSettings();
         closeSettings();
-    } else {
-        alert('Please enter a valid number between 1 and 365');
+        loadToday();
+    }
+}
+
+// Build a version 2 progress file (see the progress package) from localStorage.
+function buildProgressFile() {
+    const solutions = JSON.parse(localStorage.getItem('solutions') || '{}');
+    const drafts = JSON.parse(localStorage.getItem('drafts') || '{}');
+    const file = {
+        format: 'clanker-rehab-progress',
+        version: 2,
+        exportedAt: new Date().toISOString(),
+        settings: settings,
+        concepts: {}
+    };
+    const entry = id => (file.concepts[id] = file.concepts[id] || {});
+    Object.entries(learnedConcepts).forEach(([id, data]) => {
+        entry(id).learned = {
+            learnedAt: new Date(data.learnedAt).toISOString(),
+            expiryDays: data.expiryDays,
+            assisted: !!data.assisted,
+            hintLevel: data.hintLevel || 0,
+            quizMisses: data.quizMisses || 0,
+            pasted: !!data.pasted,
+            version: data.version || ''
+        };
+    });
+    Object.entries(solutions).forEach(([id, code]) => { entry(id).solution = code; });
+    Object.entries(drafts).forEach(([id, code]) => { entry(id).draft = code; });
+    return file;
+}
+
+function exportProgress() {
+    const blob = new Blob([JSON.stringify(buildProgressFile(), null, 2)], { type: 'application/json' });
+    const link = document.createElement('a');
+    link.href = URL.createObjectURL(blob);
+    link.download = 'clanker-rehab-progress.json';
+    link.click();
+    URL.revokeObjectURL(link.href);
+}
+
+// The server validates and migrates the file, then returns it in the current
+// format, which replaces everything in localStorage.
+async function importProgress(e) {
+    const fileInput = e.target;
+    const file = fileInput.files[0];
+    fileInput.value = '';
+    if (!file) return;
+    if (!confirm('Importing replaces your current progress, solutions and drafts. Continue?')) return;
+
+    const response = await fetch('/api/progress/import?drop_unknown=true', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: await file.text()
+    });
+    const result = await response.json();
+    if (!response.ok) {
+        alert('Import failed: ' + (result.problems ? result.problems.join('\n') : result.error));
+        return;
+    }
+
+    const learned = {}, solutions = {}, drafts = {};
+    Object.entries(result.concepts).forEach(([id, rec]) => {
+        if (rec.learned) learned[id] = learnedRecord(rec.learned);
+        if (rec.solution) solutions[id] = rec.solution;
+        if (rec.draft) drafts[id] = rec.draft;
+    });
+    localStorage.setItem('learnedConcepts', JSON.stringify(learned));
+    localStorage.setItem('solutions', JSON.stringify(solutions));
+    localStorage.setItem('drafts', JSON.stringify(drafts));
+    if (result.settings) {
+        settings = result.settings;
+        saveSettings();
     }
+    location.reload();
 }
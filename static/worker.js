@@ -8,11 +8,13 @@ self.onWasmReady = function () {
     self.postMessage({ type: 'ready' });
 };
 
-// Load wasm_exec.js (Go WASM support) and instantiate the WASM binary
-importScripts('/static/wasm_exec.js');
+// Load wasm_exec.js (Go WASM support) and instantiate the WASM binary.
+// The page passes content-hashed URLs for both in our query string.
+const assetParams = new URLSearchParams(self.location.search);
+importScripts(assetParams.get('exec') || '/static/wasm_exec.js');
 
 const go = new Go();
-WebAssembly.instantiateStreaming(fetch('/static/yaegi.wasm'), go.importObject)
+WebAssembly.instantiateStreaming(fetch(assetParams.get('wasm') || '/static/yaegi.wasm'), go.importObject)
     .then(wasmResult => {
         go.run(wasmResult.instance);
     })
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math/rand/v2"
+	"net/http"
+
+	"go-concept-trainer/progress"
+)
+
+// newSeed picks a variant seed. It is never 0, the default variant, and
+// fits in a JavaScript number so the browser can send it back unchanged.
+func newSeed() uint64 {
+	return rand.Uint64N(1<<53-1) + 1
+}
+
+// variant serves GET /api/variants/{id}: the learner's variant of a
+// parameterised concept for the current attempt. The first request of an
+// attempt picks a fresh seed, so each review after the concept is learned
+// shows new values; the ExpectedOutput comes from running the variant's
+// Answer. The seed is kept with the learner's progress, so a browser the
+// server holds none for, and every server without a sandbox-runner, gets
+// the default variant.
+func (p *progressAPI) variant(w http.ResponseWriter, r *http.Request) {
+	i, ok := p.idx.byID[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "concept not found")
+		return
+	}
+	c := &p.idx.list[i]
+	if !c.Variants {
+		writeError(w, http.StatusNotFound, "concept has no variants")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	// In an exam the variant is shown without its answer.
+	inExam := p.exams.activeConcept(r, c.ID)
+	send := func(v *Concept) {
+		if inExam {
+			writeJSON(w, http.StatusOK, forExam(*v))
+			return
+		}
+		writeJSON(w, http.StatusOK, v)
+	}
+	learner, ok, err := p.storedLearner(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "could not read stored progress")
+		return
+	}
+	if !ok || p.grader.runner == nil {
+		send(c)
+		return
+	}
+
+	var seed uint64
+	err = p.store.update(learner, func(f *progress.File) error {
+		rec := f.Concepts[c.ID]
+		if rec.Seed == 0 {
+			rec.Seed = newSeed()
+			f.Concepts[c.ID] = rec
+		}
+		seed = rec.Seed
+		return nil
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "could not store progress")
+		return
+	}
+	v, err := p.grader.variant(r.Context(), c, seed)
+	if err != nil {
+		p.grader.runFailed(w, r, err)
+		return
+	}
+	send(v)
+}
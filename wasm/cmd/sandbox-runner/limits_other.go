@@ -0,0 +1,12 @@
+//go:build !(linux || darwin)
+
+package main
+
+import "errors"
+
+func applyLimits(memory uint64, cpuSeconds uint64, threads int) error {
+	if memory > 0 || cpuSeconds > 0 || threads > 0 {
+		return errors.New("resource limits are not supported on this platform")
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"runtime/debug"
+	"syscall"
+)
+
+// applyLimits caps this process before it runs any learner code. The
+// rlimits are hard limits, so nothing the program does can raise them:
+// past the CPU limit the kernel kills the process, and past the memory
+// limit allocations fail and the runtime aborts. Memory is bounded with
+// RLIMIT_DATA rather than RLIMIT_AS because the Go runtime reserves far more
+// address space than it uses; RLIMIT_DATA counts only the writable private
+// mappings the heap lives in.
+func applyLimits(memory uint64, cpuSeconds uint64, threads int) error {
+	if memory > 0 {
+		if err := syscall.Setrlimit(syscall.RLIMIT_DATA, &syscall.Rlimit{Cur: memory, Max: memory}); err != nil {
+			return err
+		}
+	}
+	if cpuSeconds > 0 {
+		if err := syscall.Setrlimit(syscall.RLIMIT_CPU, &syscall.Rlimit{Cur: cpuSeconds, Max: cpuSeconds}); err != nil {
+			return err
+		}
+	}
+	if threads > 0 {
+		debug.SetMaxThreads(threads)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Command sandbox-runner interprets one Go program read from stdin with the
+// same package whitelist as the browser sandbox and writes the result as
+// JSON ({"output": ..., "error": ...}) to stdout.
+//
+// The server runs it as a child process to grade exercises. The parent
+// kills it on its wall-clock timeout; -max-memory, -max-cpu and
+// -max-threads are applied here, as hard limits, before the program runs.
+// It exits 1 when it cannot run the program at all. Otherwise the kernel
+// kills it at the CPU limit, and the Go runtime exits 2 when it aborts at
+// the memory or thread limit or the program panics in a goroutine it
+// started, with the reason on stderr.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"clanker-rehab-wasm/sandbox"
+)
+
+const maxSource = 64 << 10
+
+func main() {
+	limit := flag.Int("output-limit", 64<<10, "maximum bytes of stdout and of stderr")
+	maxMemory := flag.Uint64("max-memory", 0, "maximum bytes of private writable memory, the heap included (0 for no limit)")
+	maxCPU := flag.Uint64("max-cpu", 0, "maximum seconds of CPU time (0 for no limit)")
+	maxThreads := flag.Int("max-threads", 0, "maximum OS threads (0 for the runtime default)")
+	flag.Parse()
+
+	if err := applyLimits(*maxMemory, *maxCPU, *maxThreads); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox-runner: setting limits: %v\n", err)
+		os.Exit(1)
+	}
+
+	code, err := io.ReadAll(io.LimitReader(os.Stdin, maxSource+1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox-runner: %v\n", err)
+		os.Exit(1)
+	}
+	var r sandbox.Result
+	if len(code) > maxSource {
+		r.Error = "program too large"
+	} else {
+		r = sandbox.Run(string(code), *limit)
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(r); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,126 @@
+// Package sandbox runs learner Go code in the Yaegi interpreter with a
+// whitelist of standard library packages. The browser runs it compiled to
+// WebAssembly; the server runs the same code natively through the
+// sandbox-runner command to grade exercises.
+package sandbox
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/traefik/yaegi/interp"
+	"github.com/traefik/yaegi/stdlib"
+)
+
+// safePackages is an explicit whitelist of stdlib packages user code may import.
+// Dangerous packages (os, net, syscall, unsafe, os/exec, plugin, runtime) are
+// intentionally excluded as defence-in-depth on top of the WASM sandbox.
+var safePackages = map[string]bool{
+	"bufio":         true,
+	"bytes":         true,
+	"context":       true,
+	"crypto/sha256": true,
+	"encoding/json": true,
+	"errors":        true,
+	"fmt":           true,
+	"io":            true,
+	"log":           true,
+	"math":          true,
+	"math/bits":     true,
+	"math/rand":     true,
+	"reflect":       true,
+	"regexp":        true,
+	"sort":          true,
+	"strconv":       true,
+	"strings":       true,
+	"sync":          true,
+	"sync/atomic":   true,
+	"time":          true,
+	"unicode":       true,
+	"unicode/utf8":  true,
+}
+
+// safeSymbols returns a filtered interp.Exports containing only whitelisted
+// packages. Yaegi keys its symbol tables "importpath/name", e.g. "fmt/fmt".
+func safeSymbols() interp.Exports {
+	filtered := make(interp.Exports)
+	for key, symbols := range stdlib.Symbols {
+		if safePackages[path.Dir(key)] {
+			filtered[key] = symbols
+		}
+	}
+	return filtered
+}
+
+// Result is the outcome of running a program.
+type Result struct {
+	Output string `json:"output"`
+	Error  string `json:"error"`
+}
+
+// errOutputLimit stops a program that prints more than the caller allowed.
+var errOutputLimit = errors.New("output limit exceeded")
+
+// limitWriter fails writes past n bytes.
+type limitWriter struct {
+	w io.Writer
+	n int
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	if len(p) > l.n {
+		l.w.Write(p[:l.n])
+		l.n = 0
+		return 0, errOutputLimit
+	}
+	l.n -= len(p)
+	return l.w.Write(p)
+}
+
+// Run interprets code and returns what it printed, stderr after stdout.
+// limit caps stdout and stderr in bytes each; zero means no limit.
+func Run(code string, limit int) (r Result) {
+	var stdout, stderr bytes.Buffer
+	var out, errOut io.Writer = &stdout, &stderr
+	if limit > 0 {
+		out, errOut = &limitWriter{w: &stdout, n: limit}, &limitWriter{w: &stderr, n: limit}
+	}
+
+	i := interp.New(interp.Options{
+		Stdout: out,
+		Stderr: errOut,
+	})
+
+	if err := i.Use(safeSymbols()); err != nil {
+		return Result{Error: "failed to load stdlib: " + err.Error()}
+	}
+
+	defer func() {
+		// The interpreter can panic on malformed programs; report it
+		// like any other error rather than taking the host down.
+		if p := recover(); p != nil {
+			r = Result{Output: combine(&stdout, &stderr), Error: fmt.Sprint("panic: ", p)}
+		}
+	}()
+	_, err := i.Eval(code)
+
+	r = Result{Output: combine(&stdout, &stderr)}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+func combine(stdout, stderr *bytes.Buffer) string {
+	output := stdout.String()
+	if stderr.Len() > 0 {
+		if output != "" {
+			output += "\n"
+		}
+		output += stderr.String()
+	}
+	return output
+}